@@ -1,10 +1,91 @@
 package main
 
-import "github.com/downbtn/ap-maze/maze"
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/downbtn/ap-maze/maze"
+)
 
 var AVAILABLE_MAZES = []string{"maze_1", "maze_2"}
 
+// the maps are built in so the game can be run from any directory
+//
+//go:embed data
+var builtinMaps embed.FS
+
 func main() {
+	mazeName := flag.String("maze", "", "jump straight into the level called `name`")
+	generate := flag.String("generate", "", "play a freshly generated maze that's `WxH` cells")
+	seed := flag.Int64("seed", 0, "seed for -generate, so the same maze can be played again")
+	sound := flag.Bool("sound", false, "ring the terminal bell on wall bumps and wins")
+	dataDir := flag.String("data", "data", "load maps from the folder at `path`")
+	validateDir := flag.String("validate", "", "check every maze file in `dir` can be beaten and exit")
+	practice := flag.Bool("practice", false, "always show the way to the end, without keeping score")
+	flag.Parse()
+
+	if *validateDir != "" {
+		failed, err := validate(*validateDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *mazeName != "" && *generate != "" {
+		fail("-maze and -generate can't be used together")
+	}
+	if *seed != 0 && *generate == "" {
+		fail("-seed only works with -generate")
+	}
+
 	game := maze.CreateGame(AVAILABLE_MAZES)
+	defer game.Recover()
+	game.BuiltinMaps = builtinMaps
+	game.SoundEnabled = *sound
+	game.Practice = *practice
+	game.SetDataDir(*dataDir)
+
+	// these run once the menu has been set up, so quitting the level goes
+	// back to the menu like normal
+	if *mazeName != "" {
+		game.Application.QueueUpdateDraw(func() {
+			game.PlayLevel(*mazeName)
+		})
+	} else if *generate != "" {
+		width, height, err := parseSize(*generate)
+		if err != nil {
+			fail(err.Error())
+		}
+		game.Application.QueueUpdateDraw(func() {
+			game.PlayGenerated(width, height, *seed)
+		})
+	}
+
 	game.MainMenu()
 }
+
+// parseSize reads a size like 20x15.
+func parseSize(s string) (int, int, error) {
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if !ok || errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("Invalid size %q, it should look like 20x15", s)
+	}
+	return width, height, nil
+}
+
+func fail(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	flag.Usage()
+	os.Exit(2)
+}
@@ -0,0 +1,48 @@
+package maze
+
+// AutoSolve plays m without the TUI by walking the shortest path to the
+// nearest exit, and returns the score that run would get. Won is false if
+// the walk gets stuck, like at a door there's no key for, or if it loses the
+// stage on the way the same as a player would, like by walking into an enemy
+// or running out of moves. It uses the Game's play state, but like LoadMaze
+// it plays on a copy of m.
+func (g *Game) AutoSolve(m *Maze) *Score {
+	if m.PathLen < 0 {
+		if err := m.ComputePathLen(); err != nil {
+			return &Score{Map: "Auto-solve"}
+		}
+	}
+	g.LoadMaze(m, "Auto-solve")
+
+	exit, _, err := m.nearestExit(m.Start)
+	if err != nil {
+		return g.stageScore(0, false)
+	}
+	path, err := m.SolvePath(m.Start, exit)
+	if err != nil {
+		return g.stageScore(0, false)
+	}
+
+	// the moves go through TryMove so they play out the same as if they
+	// were keys being pressed
+	won := false
+	for _, next := range path[1:] {
+		moved := false
+		for _, dir := range []Direction{NEG_Y, POS_Y, NEG_X, POS_X} {
+			dx, dy := dir.delta()
+			player := Coords{X: g.PlayerX, Y: g.PlayerY}
+			if n, ok := g.CurrentMap.stepFrom(player, dx, dy); ok && n == next {
+				moved, won = g.TryMove(dir)
+				break
+			}
+		}
+		if !moved || g.stageLost(won) {
+			return g.stageScore(0, false)
+		}
+	}
+	if !won {
+		return g.stageScore(0, false)
+	}
+
+	return g.stageScore(int(CalcScoreWith(g.ScoreConfig, g.CurrentSteps, m.PathLen)), true)
+}
@@ -0,0 +1,70 @@
+package maze
+
+import "testing"
+
+func TestAutoSolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		maze      string
+		moveLimit int
+		won       bool
+	}{
+		{"plain", "#######\n#>....#\n#####<#\n#######\n", 0, true},
+		{"enough moves", "#######\n#>....#\n#####<#\n#######\n", 5, true},
+		{"out of moves", "#######\n#>....#\n#####<#\n#######\n", 3, false},
+		{"enemy in the way", "#######\n#>..E<#\n#######\n", 0, false},
+		{"door without a key", "#######\n#>.D.<#\n#######\n", 0, false},
+	}
+	for _, test := range tests {
+		m, err := LoadMazeFromString(test.maze)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		g := &Game{MoveLimit: test.moveLimit, ScoreConfig: DefaultScoreConfig()}
+		s := g.AutoSolve(m)
+		if s.Won != test.won {
+			t.Errorf("%s: got won %v, want %v", test.name, s.Won, test.won)
+		}
+		if s.Won && s.Score != int(DefaultScoreConfig().MaxScore) {
+			t.Errorf("%s: got score %d for the best path", test.name, s.Score)
+		}
+		if !s.Won && s.Score != 0 {
+			t.Errorf("%s: got score %d for a loss", test.name, s.Score)
+		}
+	}
+
+	// a Game with no ScoreConfig set scores on the default curve
+	m, err := LoadMazeFromString("#######\n#>....#\n#####<#\n#######\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := (&Game{}).AutoSolve(m); s.Score != int(DefaultScoreConfig().MaxScore) {
+		t.Errorf("zero ScoreConfig: got score %d for the best path", s.Score)
+	}
+}
+
+func TestAutoSolveGenerated(t *testing.T) {
+	for _, algorithm := range ALL_ALGORITHMS {
+		for seed := int64(1); seed <= 10; seed++ {
+			m, err := Generate(GenerateOptions{Width: 12, Height: 8, Seed: seed, Algorithm: algorithm})
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := m.String()
+			g := &Game{ScoreConfig: DefaultScoreConfig()}
+			s := g.AutoSolve(m)
+			if !s.Won {
+				t.Fatalf("%v seed %d: didn't win", algorithm, seed)
+			}
+			if g.CurrentSteps != m.PathLen {
+				t.Errorf("%v seed %d: took %d steps, want %d", algorithm, seed, g.CurrentSteps, m.PathLen)
+			}
+			if s.Score != int(DefaultScoreConfig().MaxScore) {
+				t.Errorf("%v seed %d: got score %d, want the most there is", algorithm, seed, s.Score)
+			}
+			if m.String() != before {
+				t.Errorf("%v seed %d: solving changed the maze that was passed in", algorithm, seed)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// Campaign keeps track of which levels the player has beaten in campaign mode.
+// Levels have to be beaten in order, so a level is unlocked once the one
+// before it has been beaten. Like Highscores it's saved to a JSON file.
+type Campaign struct {
+	Path   string
+	Beaten map[string]bool
+}
+
+// NewCampaign creates a Campaign with nothing beaten, backed by the file at
+// path. Call Load to read in the progress that's already saved.
+func NewCampaign(path string) *Campaign {
+	return &Campaign{
+		Path:   path,
+		Beaten: make(map[string]bool),
+	}
+}
+
+// Load reads the campaign progress from its file. A missing file is fine, it
+// just means the campaign hasn't been started yet.
+func (c *Campaign) Load() error {
+	content, err := os.ReadFile(c.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	beaten := make(map[string]bool)
+	err = json.Unmarshal(content, &beaten)
+	if err != nil {
+		return fmt.Errorf("Could not read campaign progress from %s: %v", c.Path, err)
+	}
+	c.Beaten = beaten
+	return nil
+}
+
+// Save writes the campaign progress to its file, creating its directory if it
+// doesn't exist yet.
+func (c *Campaign) Save() error {
+	content, err := json.MarshalIndent(c.Beaten, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(c.Path), 0755)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.Path, content)
+}
+
+// Unlocked says whether level i of levels can be played yet. The first level
+// is always unlocked.
+func (c *Campaign) Unlocked(levels []string, i int) bool {
+	return i == 0 || c.Beaten[levels[i-1]]
+}
+
+// Beat marks a level as beaten, which unlocks the one after it.
+func (c *Campaign) Beat(mapName string) {
+	c.Beaten[mapName] = true
+}
+
+// CampaignSelect shows the campaign screen. It's like LevelSelect, but locked
+// levels are marked and can't be played. The page is made again every time
+// since the locks change as levels get beaten.
+func (g *Game) CampaignSelect() {
+	labels := make([]string, len(g.AvailMaps))
+	var sb strings.Builder
+	sb.WriteString("CAMPAIGN\n\nBeat each map to unlock the next one.\n")
+	for i, name := range g.AvailMaps {
+		labels[i] = name
+		if !g.Campaign.Unlocked(g.AvailMaps, i) {
+			labels[i] = name + " (locked)"
+		} else if g.Campaign.Beaten[name] {
+			sb.WriteString(fmt.Sprintf("\n%s: beaten", name))
+		}
+	}
+
+	selectModal := tview.NewModal().SetText(sb.String()).AddButtons(labels).AddButtons([]string{"Main Menu"})
+	selectModal.SetDoneFunc(func(i int, label string) {
+		if label == "Main Menu" {
+			g.MainMenu()
+			return
+		}
+		if !g.Campaign.Unlocked(g.AvailMaps, i) {
+			g.okModal(fmt.Sprintf("Beat %s first to unlock this map.", g.AvailMaps[i-1]), "locked")
+			return
+		}
+		if g.LoadFile(label) {
+			g.CampaignMode = true
+			g.PlayMap(g.EndGame)
+		}
+	})
+	g.Pages.RemovePage("campaign")
+	g.Pages.AddAndSwitchToPage("campaign", selectModal, false)
+}
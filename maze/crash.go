@@ -0,0 +1,21 @@
+package maze
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// Recover puts the terminal back to normal if something panics, then prints
+// what happened and exits. Without it a crash leaves the terminal in raw mode
+// with no cursor. tview only catches panics on the UI thread, so every
+// goroutine the game starts has to defer it too.
+func (g *Game) Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	g.Application.Stop()
+	fmt.Fprintf(os.Stderr, "Sorry, the game crashed: %v\n\n%s", r, debug.Stack())
+	os.Exit(1)
+}
@@ -0,0 +1,66 @@
+package maze
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DAILY_WIDTH and DAILY_HEIGHT are the grid size of the daily challenge.
+const DAILY_WIDTH = 20
+const DAILY_HEIGHT = 15
+
+// DAILY_HISTORY is how many days of daily scores the end screen shows.
+const DAILY_HISTORY = 7
+
+// DailySeed turns the date of t into a seed like 20240131. The date is taken
+// in UTC so everyone gets the same maze on the same day.
+func DailySeed(t time.Time) int64 {
+	year, month, day := t.UTC().Date()
+	return int64(year*10000 + int(month)*100 + day)
+}
+
+// dailyName is the map name for the daily challenge on the day of t. Daily
+// scores are saved under it.
+func dailyName(t time.Time) string {
+	return "Daily " + t.UTC().Format("2006-01-02")
+}
+
+// PlayDaily starts today's daily challenge.
+func (g *Game) PlayDaily() {
+	now := time.Now()
+	m, err := GenerateMaze(DAILY_WIDTH, DAILY_HEIGHT, DailySeed(now))
+	if err != nil {
+		g.DisplayError(err)
+		return
+	}
+
+	g.LoadMaze(m, dailyName(now))
+	g.Daily = true
+	g.PlayMap(g.EndGame)
+}
+
+// recordDaily saves the score for a daily challenge and returns some lines
+// for the end screen with the best daily scores from the last few days.
+func (g *Game) recordDaily(s *Score) (string, error) {
+	g.DailyScores.Record(s.Map, s.Score)
+	err := g.DailyScores.Save()
+
+	days := make([]string, 0, len(g.DailyScores.Scores))
+	for name := range g.DailyScores.Scores {
+		days = append(days, name)
+	}
+	// the names have the date in them, so newest first is just reverse order
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	if len(days) > DAILY_HISTORY {
+		days = days[:DAILY_HISTORY]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\nYour daily history:")
+	for _, name := range days {
+		sb.WriteString(fmt.Sprintf("\n%s: %d", strings.TrimPrefix(name, "Daily "), g.DailyScores.Scores[name]))
+	}
+	return sb.String(), err
+}
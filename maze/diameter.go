@@ -0,0 +1,176 @@
+package maze
+
+import (
+	"errors"
+	"math"
+)
+
+// Diameter finds the two tiles that are furthest apart, going by the
+// shortest path between them, and how far apart they are. That's the best
+// place for a start and an end, and it's how the generators place them.
+//
+// Mud, portals, ice and one-way tiles mean it has to use DistancesFrom from
+// every tile, which is a lot slower on a big board. Everything the generators
+// make is plain, and there it can skip most of the searching.
+// Tiles that can't reach each other don't count, so a board in several
+// pieces gives the longest path within any one piece.
+func (m *Maze) Diameter() (src Coords, dest Coords, dist int, err error) {
+	var tiles []Coords
+	for y, row := range m.Board {
+		for x := range row {
+			if c := (Coords{X: x, Y: y}); m.passable(c) {
+				tiles = append(tiles, c)
+			}
+		}
+	}
+	if len(tiles) == 0 {
+		return src, dest, 0, errors.New("Maze has no open tiles")
+	}
+
+	if !m.plain() {
+		dist = -1
+		for _, from := range tiles {
+			distances, _ := m.DistancesFrom(from)
+			for y, row := range distances {
+				for x, d := range row {
+					if d > dist {
+						src, dest, dist = from, Coords{X: x, Y: y}, d
+					}
+				}
+			}
+		}
+		return src, dest, dist, nil
+	}
+
+	g := m.plainGraph(tiles)
+	from, to, dist := g.diameter()
+	return tiles[from], tiles[to], dist, nil
+}
+
+// plain reports whether every open tile costs one step and just connects to
+// the open tiles next to it, with no mud, portals, ice or one-way tiles.
+func (m *Maze) plain() bool {
+	if len(m.Portals) > 0 {
+		return false
+	}
+	for _, row := range m.Board {
+		for _, tile := range row {
+			if _, _, oneWay := tile.OneWay(); oneWay || tile == TILE_MUD || tile == TILE_ICE {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// rowLen is the length of the longest row on the board.
+func (m *Maze) rowLen() int {
+	longest := 0
+	for _, row := range m.Board {
+		longest = max(longest, len(row))
+	}
+	return longest
+}
+
+// tileGraph is the open tiles of a plain maze with the tiles next to each
+// one worked out ahead of time, so searching it over and over doesn't have to
+// look at the board every time.
+type tileGraph struct {
+	neighbors [][]int
+}
+
+// plainGraph builds the tileGraph for tiles, which has to be every open tile.
+func (m *Maze) plainGraph(tiles []Coords) *tileGraph {
+	width := m.rowLen()
+	index := make(map[int]int, len(tiles))
+	for i, c := range tiles {
+		index[c.Y*width+c.X] = i
+	}
+
+	g := &tileGraph{neighbors: make([][]int, len(tiles))}
+	for i, c := range tiles {
+		for _, n := range []Coords{{X: c.X + 1, Y: c.Y}, {X: c.X, Y: c.Y + 1}} {
+			if m.passable(n) {
+				j := index[n.Y*width+n.X]
+				g.neighbors[i] = append(g.neighbors[i], j)
+				g.neighbors[j] = append(g.neighbors[j], i)
+			}
+		}
+	}
+	return g
+}
+
+// diameter does the searching for Diameter and gives back the two tiles as
+// indexes. Searching from every tile would work, but each search also says
+// roughly how far from everything the other tiles are, and most tiles can be
+// ruled out that way without searching from them. It picks the next tile to
+// search from by going back and forth between the one that might be
+// furthest from anything and the one that's most central, which rules out
+// the most tiles.
+func (g *tileGraph) diameter() (src int, dest int, dist int) {
+	n := len(g.neighbors)
+	distances := make([]int, n)
+	queue := make([]int, 0, n)
+	// lower and upper are what's known so far about how far the furthest
+	// tile from each tile is
+	lower := make([]int, n)
+	upper := make([]int, n)
+	for i := range upper {
+		upper[i] = math.MaxInt
+	}
+
+	dist = -1
+	for round := 0; ; round++ {
+		next := -1
+		for i := range g.neighbors {
+			if upper[i] <= dist {
+				// can't beat what we've already got
+				continue
+			}
+			if next < 0 ||
+				(round%2 == 0 && upper[i] > upper[next]) ||
+				(round%2 == 1 && lower[i] < lower[next]) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return src, dest, dist
+		}
+
+		far, ecc := g.furthest(next, distances, queue)
+		if ecc > dist {
+			src, dest, dist = next, far, ecc
+		}
+		for i, d := range distances {
+			if d < 0 {
+				continue
+			}
+			lower[i] = max(lower[i], d, ecc-d)
+			upper[i] = min(upper[i], ecc+d)
+		}
+	}
+}
+
+// furthest does a breadth-first search from src and returns the tile that's
+// furthest away and how far it is. distances and queue are reused between
+// searches so it doesn't allocate, and distances is left holding how far every
+// tile is from src, or -1 if it can't be reached.
+func (g *tileGraph) furthest(src int, distances []int, queue []int) (int, int) {
+	for i := range distances {
+		distances[i] = -1
+	}
+	distances[src] = 0
+	queue = append(queue[:0], src)
+	far := src
+	for head := 0; head < len(queue); head++ {
+		c := queue[head]
+		far = c
+		for _, n := range g.neighbors[c] {
+			if distances[n] < 0 {
+				distances[n] = distances[c] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+	return far, distances[far]
+}
@@ -0,0 +1,82 @@
+package maze
+
+import "math"
+
+// DIFFICULTY_MEDIUM and DIFFICULTY_HARD are where Medium and Hard start on the
+// Difficulty scale. They came from trying it out on the built in maps and on
+// generated mazes of a few sizes.
+const DIFFICULTY_MEDIUM = 10.0
+const DIFFICULTY_HARD = 16.0
+
+// DifficultyMetrics are the things Difficulty is worked out from. Only the
+// tiles that can be reached from the start count.
+type DifficultyMetrics struct {
+	// Reachable is how many tiles can be reached from the start
+	Reachable int
+	// PathLen is the length of the shortest way out
+	PathLen int
+	// DeadEnds are tiles with only one open side
+	DeadEnds int
+	// Junctions are tiles with three or four open sides, where the player
+	// has to make a choice
+	Junctions int
+}
+
+// PathRatio is how much of the maze the shortest way out goes through.
+func (d DifficultyMetrics) PathRatio() float64 {
+	if d.Reachable == 0 {
+		return 0
+	}
+	return float64(d.PathLen) / float64(d.Reachable)
+}
+
+// Metrics measures the maze for Difficulty.
+func (m *Maze) Metrics() DifficultyMetrics {
+	var d DifficultyMetrics
+	// a maze without a start just has nothing reachable
+	dist, _ := m.DistancesFrom(m.Start)
+	for y, row := range dist {
+		for x, steps := range row {
+			if steps < 0 {
+				continue
+			}
+			d.Reachable++
+
+			sides := m.openSides(Coords{X: x, Y: y})
+			if sides == 1 {
+				d.DeadEnds++
+			} else if sides >= 3 {
+				d.Junctions++
+			}
+		}
+	}
+
+	if _, pathLen, err := m.nearestExit(m.Start); err == nil {
+		d.PathLen = pathLen
+	}
+	return d
+}
+
+// Difficulty rates how hard the maze is. Bigger mazes are harder, and so are
+// ones where the way out winds through more of the maze and there are more
+// wrong turns to take. It's only meant for comparing mazes with each other,
+// see DifficultyLabel for what the numbers mean.
+func (m *Maze) Difficulty() float64 {
+	d := m.Metrics()
+	if d.Reachable == 0 {
+		return 0
+	}
+	choices := float64(d.DeadEnds+d.Junctions) / float64(d.Reachable)
+	return math.Log2(float64(d.Reachable)) * (1 + d.PathRatio() + 2*choices)
+}
+
+// DifficultyLabel turns a Difficulty into Easy, Medium or Hard.
+func DifficultyLabel(difficulty float64) string {
+	switch {
+	case difficulty >= DIFFICULTY_HARD:
+		return "Hard"
+	case difficulty >= DIFFICULTY_MEDIUM:
+		return "Medium"
+	}
+	return "Easy"
+}
@@ -0,0 +1,245 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// editor is the state of the maze editor: the board being drawn and where the
+// cursor is on it.
+type editor struct {
+	g      *Game
+	name   string
+	board  [][]Tile
+	x      int
+	y      int
+	status string
+	view   *tview.TextView
+}
+
+// editorTiles are the keys that place something other than the tile with the
+// same character. Every tile can also be placed by typing it.
+var editorTiles = map[rune]Tile{
+	'w': TILE_WALL,
+	' ': TILE_EMPTY,
+	's': TILE_START,
+	'e': TILE_END,
+}
+
+// Editor asks for the name and size of a new map and then opens the editor.
+func (g *Game) Editor() {
+	form := tview.NewForm()
+	form.AddInputField("Name", "", 20, nil, nil)
+	form.AddInputField("Width", "21", 6, tview.InputFieldInteger, nil)
+	form.AddInputField("Height", "11", 6, tview.InputFieldInteger, nil)
+
+	form.AddButton("Edit", func() {
+		name := form.GetFormItem(0).(*tview.InputField).GetText()
+		if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			g.DisplayError(fmt.Errorf("Invalid map name: %q", name))
+			return
+		}
+		width, err := strconv.Atoi(form.GetFormItem(1).(*tview.InputField).GetText())
+		if err != nil || width < 3 {
+			g.DisplayError(errors.New("Width must be a number that's at least 3"))
+			return
+		}
+		height, err := strconv.Atoi(form.GetFormItem(2).(*tview.InputField).GetText())
+		if err != nil || height < 3 {
+			g.DisplayError(errors.New("Height must be a number that's at least 3"))
+			return
+		}
+
+		g.Pages.RemovePage("editor_setup")
+		g.openEditor(name, width, height)
+	})
+	form.AddButton("Cancel", func() {
+		g.Pages.RemovePage("editor_setup")
+		g.MainMenu()
+	})
+	form.SetBorder(true).SetTitle("Editor")
+
+	g.Pages.AddAndSwitchToPage("editor_setup", form, true)
+}
+
+// openEditor opens the editor on an empty width by height map with walls
+// around the outside.
+func (g *Game) openEditor(name string, width int, height int) {
+	board := make([][]Tile, height)
+	for i := range board {
+		board[i] = make([]Tile, width)
+		for j := range board[i] {
+			if i == 0 || j == 0 || i == height-1 || j == width-1 {
+				board[i][j] = TILE_WALL
+			} else {
+				board[i][j] = TILE_EMPTY
+			}
+		}
+	}
+
+	e := &editor{
+		g:     g,
+		name:  name,
+		board: board,
+		x:     1,
+		y:     1,
+		view:  tview.NewTextView().SetDynamicColors(true),
+	}
+	e.view.SetInputCapture(e.handleKey)
+	e.draw()
+	g.Pages.AddAndSwitchToPage("editor", e.view, true)
+}
+
+func (e *editor) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	e.status = ""
+	switch event.Key() {
+	case tcell.KeyUp:
+		e.y = max(e.y-1, 0)
+	case tcell.KeyDown:
+		e.y = min(e.y+1, len(e.board)-1)
+	case tcell.KeyLeft:
+		e.x = max(e.x-1, 0)
+	case tcell.KeyRight:
+		e.x = min(e.x+1, len(e.board[0])-1)
+	case tcell.KeyEnter:
+		e.save()
+		return nil
+	case tcell.KeyEscape:
+		e.g.Pages.RemovePage("editor")
+		e.g.MainMenu()
+		return nil
+	case tcell.KeyRune:
+		tile, ok := editorTiles[event.Rune()]
+		if !ok {
+			tile = Tile(event.Rune())
+		}
+		e.place(tile)
+	}
+
+	e.draw()
+	return nil
+}
+
+// place puts tile under the cursor. There can only be one start and one end,
+// so placing another one moves it instead.
+func (e *editor) place(tile Tile) {
+	if !tile.known() {
+		e.status = fmt.Sprintf("%c isn't a tile", tile)
+		return
+	}
+
+	if tile == TILE_START || tile == TILE_END {
+		for i, row := range e.board {
+			for j, t := range row {
+				if t == tile && (i != e.y || j != e.x) {
+					row[j] = TILE_EMPTY
+					e.status = fmt.Sprintf("Moved the %c here, there can only be one", tile)
+				}
+			}
+		}
+	}
+	e.board[e.y][e.x] = tile
+}
+
+func (e *editor) ascii() string {
+	return (&Maze{Board: e.board}).String()
+}
+
+func (e *editor) draw() {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("EDITOR: %s\n", e.name))
+	sb.WriteString("Arrows move, type a tile to place it (w/space/s/e for wall/empty/start/end)\n")
+	sb.WriteString("Enter saves, ESC quits without saving\n")
+	sb.WriteString(e.status + "\n\n")
+	for i, row := range e.board {
+		for j, tile := range row {
+			if i == e.y && j == e.x {
+				sb.WriteString(fmt.Sprintf("[black:yellow]%c[-:-]", tile))
+			} else if color, ok := e.g.Theme.tileColor(tile); ok {
+				sb.WriteString(fmt.Sprintf("[%s]%c[-]", color, tile))
+			} else {
+				sb.WriteRune(rune(tile))
+			}
+		}
+		sb.WriteRune('\n')
+	}
+	e.view.SetText(sb.String())
+}
+
+// save checks the map and writes it to the data folder. If the map can't be
+// played, the player gets told why and asked if they want to save anyway.
+func (e *editor) save() {
+	problem := ""
+	m, err := LoadMazeFromString(e.ascii())
+	if err != nil {
+		problem = err.Error()
+	} else if !e.hasTile(TILE_START) {
+		problem = "The map has no start"
+	} else if !e.hasTile(TILE_END) {
+		problem = "The map has no end"
+	} else if solvable, err := m.IsSolvable(); err != nil {
+		problem = err.Error()
+	} else if !solvable {
+		problem = "The end can't be reached from the start"
+	}
+
+	if problem == "" {
+		e.write()
+		return
+	}
+
+	warning := tview.NewModal().SetText(problem + "\n\nThis map won't be playable. Save anyway?").
+		AddButtons([]string{"Keep editing", "Save anyway"})
+	warning.SetDoneFunc(func(_ int, label string) {
+		e.g.Pages.RemovePage("editor_warning")
+		if label == "Save anyway" {
+			e.write()
+		} else {
+			e.g.Pages.SwitchToPage("editor")
+		}
+	})
+	e.g.Pages.AddAndSwitchToPage("editor_warning", warning, false)
+}
+
+func (e *editor) hasTile(tile Tile) bool {
+	for _, row := range e.board {
+		for _, t := range row {
+			if t == tile {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// write saves the map to the data folder and adds it to the level select.
+func (e *editor) write() {
+	err := os.WriteFile(filepath.Join(e.g.DataDir, e.name), []byte(e.ascii()), 0644)
+	if err != nil {
+		e.g.Pages.SwitchToPage("editor")
+		e.g.DisplayError(err)
+		return
+	}
+
+	known := false
+	for _, name := range e.g.AvailMaps {
+		known = known || name == e.name
+	}
+	if !known {
+		e.g.AvailMaps = append(e.g.AvailMaps, e.name)
+	}
+	// the level select gets made again with the new or changed map on it
+	delete(e.g.levels, e.name)
+	e.g.Pages.RemovePage("map_select")
+
+	e.status = "Saved to " + filepath.Join(e.g.DataDir, e.name)
+	e.draw()
+	e.g.Pages.SwitchToPage("editor")
+}
@@ -0,0 +1,210 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/rivo/tview"
+)
+
+// EndlessConfig sets how hard Endless mode is and how quickly it gets harder.
+// It can be read from a JSON file with LoadEndlessConfig.
+type EndlessConfig struct {
+	// StartSize is the width of the first stage's maze grid. Like the
+	// parameters to GenerateMaze this is in cells, not tiles.
+	StartSize int `json:"start_size"`
+	// Growth is how many cells wider each stage is than the one before
+	Growth int `json:"growth"`
+	// HeightRatio is how tall each stage's grid is compared to how wide it
+	// is
+	HeightRatio float64 `json:"height_ratio"`
+	// MoveBudget is how many steps each stage allows as a multiple of the
+	// best path, or 0 for no limit
+	MoveBudget float64 `json:"move_budget"`
+	// Score is the scoring curve for each stage, before the multiplier for
+	// how far into the run it is
+	Score ScoreConfig `json:"score"`
+	// Lives is how many stages can be failed before the run is over, or 0
+	// to keep retrying forever
+	Lives int `json:"lives"`
+	// Bands pick which algorithm makes the mazes as the run goes on. Each
+	// one starts at its Round and lasts until the next one starts, and
+	// before the first one GenerateMaze is used.
+	Bands []EndlessBand `json:"bands,omitempty"`
+}
+
+// EndlessBand is a stretch of an Endless run where the mazes are made with
+// Algorithm, starting from Round. Rounds count from 0.
+type EndlessBand struct {
+	Round     int       `json:"round"`
+	Algorithm Algorithm `json:"algorithm"`
+}
+
+// DefaultEndlessConfig is how Endless mode plays out of the box: start at 6
+// cells wide and grow by one every stage, each stage 0.8 times as tall as it
+// is wide. Every stage allows twice the best path's steps, scores on
+// DefaultScoreConfig, and the run ends after 3 failed stages.
+func DefaultEndlessConfig() EndlessConfig {
+	return EndlessConfig{
+		StartSize:   6,
+		Growth:      1,
+		HeightRatio: 0.8,
+		MoveBudget:  2,
+		Score:       DefaultScoreConfig(),
+		Lives:       3,
+	}
+}
+
+// LoadEndlessConfig reads an EndlessConfig from the JSON file at path, so
+// Endless can be tuned without rebuilding the game. Anything the file leaves
+// out keeps its default, and if there's no file at all it's just
+// DefaultEndlessConfig.
+func LoadEndlessConfig(path string) (*EndlessConfig, error) {
+	config := DefaultEndlessConfig()
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &config, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &config)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read Endless config from %s: %v", path, err)
+	}
+	if err = config.Validate(); err != nil {
+		return nil, fmt.Errorf("Bad Endless config in %s: %v", path, err)
+	}
+	return &config, nil
+}
+
+// Validate checks that the config makes sense.
+func (c EndlessConfig) Validate() error {
+	if c.StartSize < 2 || c.StartSize > MAX_GENERATE_SIZE {
+		return fmt.Errorf("Starting size must be between 2 and %d, got %d", MAX_GENERATE_SIZE, c.StartSize)
+	}
+	if c.Growth < 0 {
+		return fmt.Errorf("Growth can't be negative, got %d", c.Growth)
+	}
+	if c.HeightRatio <= 0 {
+		return fmt.Errorf("Height ratio must be more than 0, got %g", c.HeightRatio)
+	}
+	if c.MoveBudget != 0 && c.MoveBudget < 1 {
+		return errors.New("Move budget must be at least 1, or 0 for no limit")
+	}
+	if c.Lives < 0 {
+		return fmt.Errorf("Lives can't be negative, got %d", c.Lives)
+	}
+	for i, band := range c.Bands {
+		if band.Round < 0 {
+			return fmt.Errorf("Bands can't start before round 0, got %d", band.Round)
+		}
+		if i > 0 && band.Round <= c.Bands[i-1].Round {
+			return errors.New("Bands have to be in order of the round they start at")
+		}
+		if int(band.Algorithm) >= len(algorithmNames) {
+			return fmt.Errorf("Unknown maze algorithm: %d", band.Algorithm)
+		}
+	}
+	return c.Score.Validate()
+}
+
+// Size returns the grid size to generate for a round, counting from 0. Once
+// it reaches MAX_GENERATE_SIZE it stops growing.
+func (c EndlessConfig) Size(round int) (width int, height int) {
+	width = min(c.StartSize+c.Growth*round, MAX_GENERATE_SIZE)
+	height = min(int(float64(width)*c.HeightRatio), MAX_GENERATE_SIZE)
+	if height < 2 {
+		height = 2
+	}
+	return width, height
+}
+
+// Algorithm returns which algorithm makes the maze for a round, counting
+// from 0.
+func (c EndlessConfig) Algorithm(round int) Algorithm {
+	algorithm := ALGORITHM_DFS
+	for _, band := range c.Bands {
+		if band.Round > round {
+			break
+		}
+		algorithm = band.Algorithm
+	}
+	return algorithm
+}
+
+// MinPath is the shortest best path a width by height stage is allowed to
+// have, so no stage is over almost as soon as it starts. Most mazes have a
+// path a lot longer than this, it's only there to throw out the odd short
+// one without having to generate too many times. Algorithms other than dfs
+// often can't reach it on the bigger rounds, and then the stage just gets
+// the longest maze generateMinPath found.
+func (c EndlessConfig) MinPath(width int, height int) int {
+	return width * height / 2
+}
+
+// MoveLimit returns how many steps a stage with the given best path allows,
+// or 0 if there's no limit.
+func (c EndlessConfig) MoveLimit(pathLen int) int {
+	if c.MoveBudget == 0 || pathLen < 0 {
+		return 0
+	}
+	return int(math.Ceil(c.MoveBudget * float64(pathLen)))
+}
+
+// EndlessSetup shows a form for picking the EndlessConfig, and starts Endless
+// mode with it.
+func (g *Game) EndlessSetup() {
+	c := g.EndlessConfig
+	form := tview.NewForm()
+	form.AddInputField("Starting size", strconv.Itoa(c.StartSize), 6, tview.InputFieldInteger, nil)
+	form.AddInputField("Growth per stage", strconv.Itoa(c.Growth), 6, tview.InputFieldInteger, nil)
+	form.AddInputField("Move budget (x best, 0 = none)", strconv.FormatFloat(c.MoveBudget, 'g', -1, 64), 6, tview.InputFieldFloat, nil)
+	form.AddInputField("Lives (0 = unlimited)", strconv.Itoa(c.Lives), 6, tview.InputFieldInteger, nil)
+
+	form.AddButton("Start", func() {
+		// anything that isn't on the form stays how it was
+		config := c
+		var err error
+		config.StartSize, err = strconv.Atoi(form.GetFormItem(0).(*tview.InputField).GetText())
+		if err != nil {
+			g.DisplayError(fmt.Errorf("Invalid starting size: %v", err))
+			return
+		}
+		config.Growth, err = strconv.Atoi(form.GetFormItem(1).(*tview.InputField).GetText())
+		if err != nil {
+			g.DisplayError(fmt.Errorf("Invalid growth: %v", err))
+			return
+		}
+		config.MoveBudget, err = strconv.ParseFloat(form.GetFormItem(2).(*tview.InputField).GetText(), 64)
+		if err != nil {
+			g.DisplayError(fmt.Errorf("Invalid move budget: %v", err))
+			return
+		}
+		config.Lives, err = strconv.Atoi(form.GetFormItem(3).(*tview.InputField).GetText())
+		if err != nil {
+			g.DisplayError(fmt.Errorf("Invalid lives: %v", err))
+			return
+		}
+		if err = config.Validate(); err != nil {
+			g.DisplayError(err)
+			return
+		}
+
+		g.EndlessConfig = config
+		g.Pages.RemovePage("endless_setup")
+		g.PlayEndless()
+	})
+	form.AddButton("Cancel", func() {
+		g.Pages.RemovePage("endless_setup")
+		g.MainMenu()
+	})
+	form.SetBorder(true).SetTitle("Endless")
+
+	g.Pages.AddAndSwitchToPage("endless_setup", form, true)
+}
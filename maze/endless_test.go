@@ -0,0 +1,51 @@
+package maze
+
+import "testing"
+
+func TestEndlessGeneratesLaterRounds(t *testing.T) {
+	// most algorithms don't make paths as long as dfs does, so the bigger
+	// rounds hardly ever reach MinPath, and that mustn't end the run
+	for _, algorithm := range ALL_ALGORITHMS {
+		config := DefaultEndlessConfig()
+		config.Bands = []EndlessBand{{Round: 0, Algorithm: algorithm}}
+		for _, round := range []int{10, 20, 25} {
+			width, height := config.Size(round)
+			for seed := int64(1); seed <= 5; seed++ {
+				opts := GenerateOptions{Width: width, Height: height, Seed: seed, Algorithm: config.Algorithm(round)}
+				m, err := generateMinPath(opts, config.MinPath(width, height))
+				if err != nil {
+					t.Fatalf("%v round %d seed %d: %v", algorithm, round, seed, err)
+				}
+				if m == nil || m.PathLen <= 0 {
+					t.Fatalf("%v round %d seed %d: got no maze to play", algorithm, round, seed)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateMinPathPrefersLongEnough(t *testing.T) {
+	m, err := GenerateMazeMinPath(10, 10, 1, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.PathLen < 40 {
+		t.Errorf("got a path of %d, want at least 40", m.PathLen)
+	}
+
+	// no 3x3 maze has a path anywhere near this long, so it settles for
+	// the longest
+	m, err = GenerateMazeMinPath(3, 3, 1, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for seed := int64(1); seed <= MIN_PATH_RETRIES; seed++ {
+		other, err := GenerateMaze(3, 3, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other.PathLen > m.PathLen {
+			t.Fatalf("seed %d has a path of %d, longer than the %d that was picked", seed, other.PathLen, m.PathLen)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package maze
+
+// Enemies patrol back and forth in a straight line, taking one step every
+// time the player moves. They're drawn on the map as E for one that walks
+// left and right and N for one that walks up and down, and the tile under
+// them is empty. Getting caught by one loses the stage.
+const TILE_ENEMY_HORIZONTAL Tile = 'E'
+const TILE_ENEMY_VERTICAL Tile = 'N'
+
+// ENEMY_GLYPH is what enemies are drawn as during play.
+const ENEMY_GLYPH = '&'
+
+// Enemy is one patrolling enemy. DX and DY are the way it's walking.
+type Enemy struct {
+	Pos Coords
+	DX  int
+	DY  int
+}
+
+// newEnemy makes an enemy from its tile on the map, or returns false if the
+// tile isn't an enemy.
+func newEnemy(t Tile, pos Coords) (Enemy, bool) {
+	switch t {
+	case TILE_ENEMY_HORIZONTAL:
+		return Enemy{Pos: pos, DX: 1}, true
+	case TILE_ENEMY_VERTICAL:
+		return Enemy{Pos: pos, DY: 1}, true
+	}
+	return Enemy{}, false
+}
+
+// enemyCanEnter reports whether an enemy can walk onto c. Enemies stay on
+// plain floor so they don't mess with keys, portals and the like.
+func (m *Maze) enemyCanEnter(c Coords) bool {
+	t, ok := m.tileAt(c.X, c.Y)
+	return ok && (t == TILE_EMPTY || t == TILE_MUD)
+}
+
+// stepEnemies moves every enemy one step along its patrol, turning around
+// when it hits something. It reports whether any of them caught the player,
+// which happens if the player walked into an enemy or an enemy walked into
+// the player.
+func (m *Maze) stepEnemies(to Coords) bool {
+	caught := false
+	for i := range m.Enemies {
+		e := &m.Enemies[i]
+		old := e.Pos
+		next := Coords{X: e.Pos.X + e.DX, Y: e.Pos.Y + e.DY}
+		if !m.enemyCanEnter(next) {
+			e.DX, e.DY = -e.DX, -e.DY
+			next = Coords{X: e.Pos.X + e.DX, Y: e.Pos.Y + e.DY}
+		}
+		if m.enemyCanEnter(next) {
+			e.Pos = next
+		}
+
+		if e.Pos == to || old == to {
+			caught = true
+		}
+	}
+	return caught
+}
+
+// enemyAt reports whether there's an enemy on c.
+func (m *Maze) enemyAt(c Coords) bool {
+	for _, e := range m.Enemies {
+		if e.Pos == c {
+			return true
+		}
+	}
+	return false
+}
@@ -3,9 +3,13 @@ package maze
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"math"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	tcell "github.com/gdamore/tcell/v2"
@@ -16,23 +20,138 @@ import (
 // fail and if they succeed they take a certain number of steps. It's used to
 // make other threads wait for a game to finish.
 type Score struct {
-	Score int
-	Won   bool
-	Map   string
+	Score      int
+	Won        bool
+	Map        string
+	Collisions int
+	Hints      int
+	Peeks      int
+	Steps      int
+	// Treasure is how much treasure was picked up
+	Treasure int
+	// Replay is the recording of the stage. For a generated maze it also
+	// has the seed to make it again.
+	Replay *Replay
 }
 
+// COUNTDOWN is how many seconds PlayMap counts down before a stage starts.
+const COUNTDOWN = 3
+
+// WIN_BLINKS is how many times the player blinks on the exit after winning,
+// and WIN_BLINK_DELAY is how long each half of a blink takes.
+const WIN_BLINKS = 3
+const WIN_BLINK_DELAY = 150 * time.Millisecond
+
+// HINT_PENALTY is how many points each hint costs at the end of a stage.
+const HINT_PENALTY int = 50000
+
+// PEEK_PENALTY is how many points each peek through the fog costs at the end
+// of a stage, and PEEK_DURATION is how long a peek lasts.
+const PEEK_PENALTY int = 25000
+const PEEK_DURATION = 2 * time.Second
+
+// TREASURE_BONUS is how many points each treasure is worth at the end of a
+// stage. It's about what one or two extra steps cost, so a treasure right next
+// to the path is worth grabbing and one far away isn't.
+const TREASURE_BONUS int = 50000
+
+// ScoreConfig is the shape of the scoring curve. A run that matches the best
+// path gets MaxScore, and the score drops off along an S curve the more steps
+// it goes over.
+type ScoreConfig struct {
+	MaxScore float64 `json:"max_score"`
+	// Softness is how forgiving the curve is. It has to be more than 0. With
+	// the default of 15, it takes about 16 extra steps to lose half the points,
+	// and bigger numbers take more.
+	Softness float64 `json:"softness"`
+}
+
+// DefaultScoreConfig is the curve the game has always used.
+func DefaultScoreConfig() ScoreConfig {
+	return ScoreConfig{MaxScore: 1000000, Softness: 15}
+}
+
+// Validate checks that the config makes sense.
+func (c ScoreConfig) Validate() error {
+	if c.MaxScore <= 0 {
+		return fmt.Errorf("Max score must be more than 0, got %g", c.MaxScore)
+	}
+	if c.Softness <= 0 {
+		return fmt.Errorf("Score softness must be more than 0, got %g", c.Softness)
+	}
+	return nil
+}
+
+// curve is the score for being diff over the best, in steps or seconds. It
+// never goes outside 0 to MaxScore, even for a diff below 0, which can happen
+// if the best was worked out wrong or someone beats par time.
+func (c ScoreConfig) curve(diff float64) float64 {
+	// this is (1 - e^-x) / (1 + e^-x) written so it doesn't turn into
+	// Inf/Inf when diff is a long way below 0
+	coef := math.Tanh(diff / (2 * c.Softness))
+	return math.Max(0, math.Min(c.MaxScore, c.MaxScore*(1-coef)))
+}
+
+// CalcScoreWith scores a run on the curve described by cfg. If cfg doesn't
+// pass Validate, like the zero value, it uses DefaultScoreConfig instead.
+func CalcScoreWith(cfg ScoreConfig, steps int, bestSteps int) float64 {
+	if cfg.Validate() != nil {
+		cfg = DefaultScoreConfig()
+	}
+	return cfg.curve(float64(steps - bestSteps))
+}
+
+// CalcScore scores a run with DefaultScoreConfig.
 func CalcScore(steps int, bestSteps int) float64 {
-	diff := float64(steps - bestSteps)
-	coef := (1 - math.Exp(-diff/15)) / (1 + math.Exp(-diff/15))
-	return 1000000 * (1 - coef)
+	return CalcScoreWith(DefaultScoreConfig(), steps, bestSteps)
+}
+
+// CalcScoreTimed scores a run by how long it took instead of how many steps.
+// Par time is how long the best path takes at STEPS_PER_SECOND, and each
+// second over par costs about as much as an extra step does in CalcScore.
+func CalcScoreTimed(elapsed time.Duration, bestSteps int) float64 {
+	diff := elapsed.Seconds() - float64(bestSteps)/STEPS_PER_SECOND
+	return DefaultScoreConfig().curve(diff)
 }
 
+// CalcScoreEndless scores a stage of Endless mode with DefaultScoreConfig,
+// including the multiplier for how far into the run it is.
 func CalcScoreEndless(steps int, bestSteps int, round int) float64 {
-	multiplier := 1 + math.Pow(float64(round), 2)/32
-	score := multiplier * CalcScore(steps, bestSteps)
+	score := EndlessMultiplier(round) * CalcScore(steps, bestSteps)
 	return score
 }
 
+// EndlessMultiplier is how much a stage's score is multiplied by in Endless
+// mode, which goes up the more rounds have been cleared. Rounds count from 0,
+// and anything below that gets the multiplier for round 0.
+func EndlessMultiplier(round int) float64 {
+	if round < 0 {
+		round = 0
+	}
+	return 1 + math.Pow(float64(round), 2)/32
+}
+
+// Move is an entry in the undo history. It remembers where the player was
+// before the move and anything the move changed, so it can be taken back.
+type Move struct {
+	From Coords
+	Keys int
+	// Changed is set when the move picked up a key or opened a door, in
+	// which case Tile is what used to be on the tile the player moved to.
+	Changed bool
+	Tile    Tile
+	// Steps is how many steps the move counted for
+	Steps    int
+	Bombs    int
+	Treasure int
+	// Blasted is set when this wasn't a move at all but a bomb going off,
+	// in which case Target is the wall it blew up.
+	Blasted bool
+	Target  Coords
+	// Enemies is where the enemies were before the move
+	Enemies []Enemy
+}
+
 // Game represents the running state of a game, both the board state and
 // also the TUI state.
 type Game struct {
@@ -44,9 +163,105 @@ type Game struct {
 	CurrentSteps   int
 	Endless        bool
 	EndlessRounds  int
-	PlayerX        int
-	PlayerY        int
-	//ScoreChannel   chan *Score
+	EndlessConfig  EndlessConfig
+	// Lives is how many more stages the player can fail before their
+	// Endless run is over. It's only used if EndlessConfig has Lives set.
+	Lives int
+	// ScoreConfig is the scoring curve for levels. Endless mode has its own
+	// in EndlessConfig.
+	ScoreConfig ScoreConfig
+	// MoveLimit is how many steps the player gets to reach the end before
+	// the stage is lost, or 0 for no limit
+	MoveLimit    int
+	PlayerX      int
+	PlayerY      int
+	ScoreChannel chan *Score
+	Highscores   *Highscores
+	Stats        *Stats
+	// DailyScores are the best score for each day's daily challenge
+	DailyScores *Highscores
+	// Stars are the best star rating each level has been beaten with
+	Stars *Stars
+	// Ghosts are the best run on each level, which PlayMap shows as a ghost
+	// to race against
+	Ghosts *Ghosts
+	// Daily is set when the current map is the daily challenge
+	Daily         bool
+	AllowDiagonal bool
+	Keys          int
+	Bombs         int
+	// Treasure is how much treasure has been picked up this stage
+	Treasure int
+	// FacingX and FacingY are the last way the player tried to move, which
+	// is where a bomb goes off
+	FacingX int
+	FacingY int
+	History []Move
+	// In hardcore mode running into a wall loses the stage
+	HardcoreMode bool
+	// In no backtrack mode the player can't step back onto a tile they've
+	// already left, so a wrong turn into a dead end loses the stage
+	NoBacktrack bool
+	// In practice mode the shortest way to the end is always shown, and
+	// nothing is scored or recorded
+	Practice          bool
+	CurrentCollisions int
+	KeyMap            KeyMap
+	// In timed mode the score is based on how long the stage took, counted
+	// from the first move
+	Timed     bool
+	StartTime time.Time
+	timerStop chan struct{}
+	// PausedTime is how long the pause menu has been open this stage, which
+	// doesn't count towards the time
+	PausedTime time.Duration
+	pausedAt   time.Time
+	HintsUsed  int
+	// FogRadius is how far the player can see, or 0 to see the whole maze
+	FogRadius int
+	// Peeks is how many times per stage the player can see through the fog
+	// for a moment, and PeeksUsed is how many they've used this stage
+	Peeks     int
+	PeeksUsed int
+	// ShowTrail marks every tile the player has walked on
+	ShowTrail bool
+	// Caught is set when an enemy has caught the player
+	Caught bool
+	// Trapped is set when the player has nowhere left to go in a mode where
+	// they can't get out of it, see trapped
+	Trapped bool
+	// mu guards the play state that changes with every move: the player's
+	// position, CurrentSteps, Keys, History and the board itself. Anything
+	// reading those from outside the UI thread should use PlayState.
+	mu sync.Mutex
+	// loadedMap is the maze LoadMaze was given, before any changes
+	loadedMap *Maze
+	// levels are the maps the level select has read so far, by name, so it
+	// doesn't have to read them all again every time it's made
+	levels map[string]*Maze
+	// Recording is the replay of the stage being played
+	Recording *Replay
+	// onComplete is what PlayMap was last told to do when a stage ends, so
+	// retrying a stage can do the same thing
+	onComplete func(*Score)
+	Campaign   *Campaign
+	// CampaignMode is set when the current map was started from the
+	// campaign screen
+	CampaignMode bool
+	// SavePath is where Save & Quit puts the game
+	SavePath string
+	// SoundEnabled rings the terminal bell on blocked moves and wins
+	SoundEnabled bool
+	pendingBeeps int
+	// BuiltinMaps holds a data folder of maps to fall back on when a map
+	// isn't in the data folder on disk, so the game works from anywhere
+	BuiltinMaps fs.FS
+	// DataDir is the folder on disk that maps are loaded from and the
+	// editor saves to. Use SetDataDir to change it.
+	DataDir string
+	// Theme is the colors the board is drawn in
+	Theme    Theme
+	Settings *Settings
 }
 
 // CreateGame creates a Game struct. You need to populate the data yourself
@@ -59,6 +274,19 @@ func CreateGame(levels []string) *Game {
 		AvailMaps:      levels,
 		PlayerX:        -1,
 		PlayerY:        -1,
+		Highscores:     NewHighscores(dataPath("scores.json")),
+		Campaign:       NewCampaign(dataPath("campaign.json")),
+		Stats:          NewStats(dataPath("stats.json")),
+		DailyScores:    NewHighscores(dataPath("daily.json")),
+		Stars:          NewStars(dataPath("stars.json")),
+		Ghosts:         NewGhosts(dataPath("ghosts.json")),
+		SavePath:       dataPath("save.json"),
+		DataDir:        "data",
+		Theme:          ClassicTheme(),
+		Settings:       NewSettings(dataPath("settings.json")),
+		KeyMap:         DefaultKeyMap(),
+		EndlessConfig:  DefaultEndlessConfig(),
+		ScoreConfig:    DefaultScoreConfig(),
 	}
 }
 
@@ -66,16 +294,46 @@ func (g *Game) LevelSelect() {
 	if g.Pages.HasPage("map_select") {
 		g.Pages.SwitchToPage("map_select")
 	} else {
-		selectModal := tview.NewModal().SetText("Which map would you like to play?").AddButtons(g.AvailMaps).AddButtons([]string{"Exit"})
-		selectModal.SetDoneFunc(func(_ int, label string) {
-			if label == "Exit" {
-				g.Application.Stop()
+		// a list rather than a modal since there can be more maps than
+		// buttons fit on the screen, and it scrolls
+		list := tview.NewList().ShowSecondaryText(false)
+		for _, name := range g.AvailMaps {
+			name := name
+			list.AddItem(g.levelLabel(name), "", 0, func() {
+				g.showLeaderboard(name)
+			})
+		}
+		list.AddItem("Exit", "", 0, func() {
+			g.Application.Stop()
+		})
+		list.SetDoneFunc(func() {
+			g.MainMenu()
+		})
+		list.SetBorder(true).SetTitle("Which map would you like to play?")
+
+		// the preview is a minimap of whichever map is picked in the list
+		preview := tview.NewTextView()
+		preview.SetBorder(true).SetTitle("Preview")
+		showPreview := func(i int) {
+			if i >= len(g.AvailMaps) {
+				preview.SetText("")
+				return
+			}
+			m, err := g.level(g.AvailMaps[i])
+			if err != nil {
+				preview.SetText("This map can't be read")
 				return
 			}
-			g.LoadFile(label)
-			g.PlayMap()
+			preview.SetText(m.DisplayMinimap(-1, -1, m.minimapScale()))
+		}
+		list.SetChangedFunc(func(i int, _ string, _ string, _ rune) {
+			showPreview(i)
 		})
-		g.Pages.AddAndSwitchToPage("map_select", selectModal, false)
+		showPreview(0)
+
+		// the extra 2 is for the border
+		layout := tview.NewFlex().AddItem(list, 0, 1, true).AddItem(preview, MINIMAP_WIDTH+2, 0, false)
+		g.Pages.AddAndSwitchToPage("map_select", layout, true)
 	}
 
 }
@@ -87,20 +345,71 @@ func (g *Game) MainMenu() {
 		g.Pages.SwitchToPage("menu")
 	} else {
 		menu := tview.NewModal().SetText("The Labyrinth\n\nA simple roguelike maze game made by Daniel Ha")
-		//menu = menu.AddButtons([]string{"Levels", "Endless", "Credits"})
-		menu = menu.AddButtons([]string{"Levels", "Credits"}) // Endless doesn't work right now
+		menu = menu.AddButtons([]string{"Continue", "Campaign", "Levels", "Endless", "Daily", "Editor", "Highscores", "Stats", "Settings", "Credits"})
 		menu.SetDoneFunc(func(_ int, btn string) {
 			switch btn {
 			case "Credits":
 				g.displayCopyright()
+			case "Highscores":
+				g.displayHighscores()
+			case "Stats":
+				g.displayStats()
+			case "Settings":
+				g.displaySettings()
+			case "Continue":
+				g.continueGame()
+			case "Campaign":
+				g.CampaignSelect()
 			case "Levels":
 				g.LevelSelect()
 			case "Endless":
-				g.PlayEndless()
+				g.EndlessSetup()
+			case "Daily":
+				g.PlayDaily()
+			case "Editor":
+				g.Editor()
 			}
 		})
 
 		g.Pages.AddAndSwitchToPage("menu", menu, true)
+
+		// only load the scores the first time the menu opens
+		err := g.Highscores.Load()
+		if err != nil {
+			g.DisplayError(err)
+		}
+		err = g.Campaign.Load()
+		if err != nil {
+			g.DisplayError(err)
+		}
+		err = g.Stats.Load()
+		if err != nil {
+			g.DisplayError(err)
+		}
+		err = g.DailyScores.Load()
+		if err != nil {
+			g.DisplayError(err)
+		}
+		err = g.Stars.Load()
+		if err != nil {
+			g.DisplayError(err)
+		}
+		err = g.Ghosts.Load()
+		if err != nil {
+			g.DisplayError(err)
+		}
+		err = g.Settings.Load()
+		if err != nil {
+			g.DisplayError(err)
+		} else if theme, ok := ThemeByName(g.Settings.Theme); ok {
+			g.SetTheme(theme)
+		}
+		endless, err := LoadEndlessConfig(dataPath("endless.json"))
+		if err != nil {
+			g.DisplayError(err)
+		} else {
+			g.EndlessConfig = *endless
+		}
 	}
 
 	g.Application = g.Application.SetRoot(g.Pages, true)
@@ -136,30 +445,67 @@ func (g *Game) DisplayError(err error) {
 }
 
 func (g *Game) PauseMenu() {
-	menu := tview.NewModal().SetText("GAME PAUSED\nWhat would you like to do?").AddButtons([]string{"Quit to menu", "Copyright", "Help"})
+	buttons := []string{"Quit to menu", "Copyright", "Help"}
+	if !g.Endless {
+		buttons = append([]string{"Save & Quit"}, buttons...)
+	}
+	menu := tview.NewModal().SetText("GAME PAUSED\nWhat would you like to do?").AddButtons(buttons)
 	menu.SetDoneFunc(func(_ int, label string) {
 		switch label {
+		case "Save & Quit":
+			g.Pages.RemovePage("pause")
+			err := g.saveAndQuit()
+			if err != nil {
+				g.resumeClock()
+				g.DisplayError(err)
+			}
+			return
 		case "Quit to menu":
+			g.Pages.RemovePage("pause")
 			g.ClearGame()
 			g.MainMenu()
+			return
 		case "Help":
 			help := `Welcome to my maze game!
-Controls: arrow keys to move, ESC to open menu
+Controls: arrow keys, WASD or HJKL to move, ESC to open menu
+Hold Shift with an arrow key to keep going until something stops you.
+(Q/E/Z/C or the numpad move diagonally if it's turned on)
+Backspace takes back your last move.
+? gives you a hint, but it costs points.
+T shows the trail of tiles you've walked on.
 Tiles: @ is your player. You start on >. Your goal is
-to make it to the >. # is a wall, you can't run into walls.`
+to make it to the >. # is a wall, you can't run into walls.
+k is a key. Picking one up lets you open one D (door).
+m is mud. It takes 3 steps to walk through.
+Numbers are portals. Step on one to go to the other one with the same number.
+^ v { } are one-way. Once you're on one you can only leave the way it points.
+i is ice. You slide across it until something stops you.
+b is a bomb. Press space to blow up the wall in front of you.
+$ is treasure. It's worth extra points if you make it out.
+In the fog, P lets you see the whole maze for a moment, but it costs points.
+& is an enemy. They walk back and forth each time you move, don't let them catch you.
+○ is the ghost of your best run on the level. Try to beat it!`
 			g.okModal(help, "help")
 		default:
 			g.DisplayError(errors.New("Invalid option"))
 		}
 
-		g.Pages.RemovePage("menu")
+		g.resumeClock()
+		g.Pages.RemovePage("pause")
 	})
 
-	g.Pages.AddAndSwitchToPage("menu", menu, true)
+	// this can't be called "menu" or it would replace the main menu
+	g.Pages.AddAndSwitchToPage("pause", menu, true)
 
 }
 
 func (g *Game) ClearGame() {
+	if g.ScoreChannel != nil {
+		// this stops the Endless goroutine if there is one
+		close(g.ScoreChannel)
+		g.ScoreChannel = nil
+	}
+
 	if g.CurrentMapName == "none" {
 		// game is not running
 		return
@@ -168,172 +514,1221 @@ func (g *Game) ClearGame() {
 	g.CurrentMapName = "none"
 	g.CurrentMap = nil
 	g.CurrentSteps = 0
+	if g.Endless {
+		// the limit came from the Endless config
+		g.MoveLimit = 0
+	}
 	g.Endless = false
 	g.EndlessRounds = 0
+	g.Lives = 0
+	g.Keys = 0
+	g.Treasure = 0
+	g.History = nil
+	g.CurrentCollisions = 0
+	g.resetClock()
+	g.HintsUsed = 0
+	g.PeeksUsed = 0
+	g.Recording = nil
+	g.onComplete = nil
+	g.CampaignMode = false
+	g.Daily = false
 	g.Pages.RemovePage("game")
 }
 
-func (g *Game) LoadFile(mapId string) {
+// SetDataDir points the game at a different folder of maps. The level select
+// gets made again next time it opens, since the labels come from the maps.
+func (g *Game) SetDataDir(path string) {
+	g.DataDir = path
+	g.levels = nil
+	g.Pages.RemovePage("map_select")
+}
+
+// LoadFile loads a map from the data folder. If the map can't be loaded or
+// can't be beaten, it shows an error and returns false.
+func (g *Game) LoadFile(mapId string) bool {
+	return g.LoadFrom(g.levelReader(mapId), mapId)
+}
+
+// levelReader is where a map gets read from. Maps in the data folder on disk
+// come first, so they can be edited, and then the built in ones.
+func (g *Game) levelReader(mapId string) MazeReader {
+	onDisk := FileReader{Path: filepath.Join(g.DataDir, mapId)}
+	if g.BuiltinMaps == nil {
+		return onDisk
+	}
+	if _, err := os.Stat(onDisk.Path); errors.Is(err, fs.ErrNotExist) {
+		// embedded files always use forward slashes
+		return FSReader{FS: g.BuiltinMaps, Path: "data/" + mapId}
+	}
+	return onDisk
+}
+
+// level reads the map called name for the level select. Each map is only read
+// once, after that the same *Maze is handed back, so it mustn't be changed.
+// Playing a map reads it again with LoadFile instead.
+func (g *Game) level(name string) (*Maze, error) {
+	if m, ok := g.levels[name]; ok {
+		return m, nil
+	}
+	m, err := g.levelReader(name).Read()
+	if err != nil {
+		return nil, err
+	}
+	if g.levels == nil {
+		g.levels = make(map[string]*Maze)
+	}
+	g.levels[name] = m
+	return m, nil
+}
+
+// LoadFrom reads a map from r and loads it as name, checking it can be
+// beaten first. If it can't, it shows an error and returns false.
+func (g *Game) LoadFrom(r MazeReader, name string) bool {
 	// Load map and store pointer in the Game struct
-	currentMap, err := LoadMazeFromFile("data/" + mapId)
+	currentMap, err := r.Read()
 	if err != nil {
 		g.DisplayError(err)
-		return
+		return false
+	}
+
+	solvable, err := currentMap.IsSolvable()
+	if err != nil {
+		g.DisplayError(err)
+		return false
+	} else if !solvable {
+		g.DisplayError(fmt.Errorf("Map %s can't be solved: the end can't be reached from the start", name))
+		return false
+	}
+
+	err = currentMap.ComputePathLen()
+	if err != nil {
+		g.DisplayError(err)
+		return false
 	}
-	g.LoadMaze(currentMap, mapId)
+
+	g.LoadMaze(currentMap, name)
+	return true
 }
 
+// LoadMaze gets the game ready to play m. The game plays on a copy, since
+// playing changes the board, so m itself is left alone and can be loaded
+// again to start over.
 func (g *Game) LoadMaze(m *Maze, name string) {
-	g.CurrentMap = m
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.loadedMap = m
+	g.CurrentMap = m.Clone()
 	g.PlayerX = g.CurrentMap.Start.X
 	g.PlayerY = g.CurrentMap.Start.Y
 	g.CurrentMapName = name
 	g.CurrentSteps = 0
+	g.Keys = 0
+	g.Treasure = 0
+	g.Bombs = 0
+	g.FacingX, g.FacingY = 0, 0
+	g.Caught = false
+	g.Trapped = false
+	g.History = nil
+	g.CurrentCollisions = 0
+	g.resetClock()
+	g.HintsUsed = 0
+	g.PeeksUsed = 0
+	g.Recording = newReplay(m, name)
 }
 
 func (g *Game) EndGame(s *Score) {
+	var saveErr, campaignErr, starsErr error
 	endScreen := tview.NewModal()
-	if g.Endless {
+	// runEndless only loads the next stage once this one's been won. A
+	// failed stage can only be retried, which loads the same maze again.
+	if g.Endless && s.Won {
 		endScreen = endScreen.AddButtons([]string{"Continue"})
 	}
+	if g.Practice {
+		g.practiceEnd(endScreen, s)
+		return
+	}
 	if s.Won {
+		// hints and peeks aren't free, but treasure makes up for them
+		s.Score += s.Treasure * TREASURE_BONUS
+		if s.Hints > 0 || s.Peeks > 0 {
+			s.Score -= s.Hints*HINT_PENALTY + s.Peeks*PEEK_PENALTY
+			if s.Score < 0 {
+				s.Score = 0
+			}
+		}
+
 		text := fmt.Sprintf(`STAGE CLEAR: %s
 Congratulations!
-Your score was: %d`, s.Map, s.Score)
-		endScreen = endScreen.SetText(text).AddButtons([]string{"Main Menu"})
+Your score was: %d
+Wall bumps: %d
+Hints used: %d`, g.mapTitle(s.Map), s.Score, s.Collisions, s.Hints)
+		if s.Treasure > 0 {
+			text += fmt.Sprintf("\nTreasure found: %d", s.Treasure)
+		}
+		if s.Peeks > 0 {
+			text += fmt.Sprintf("\nPeeks used: %d", s.Peeks)
+		}
+		// generated mazes aren't levels, so they don't get a highscore
+		if !g.Endless && g.CurrentMap.Seed == 0 {
+			var best string
+			best, saveErr = g.recordHighscore(s)
+			text += best
+			var stars string
+			stars, starsErr = g.recordStars(s)
+			text += stars
+		}
+		if g.Daily {
+			var history string
+			history, saveErr = g.recordDaily(s)
+			text += history
+		}
+		if g.CampaignMode {
+			var unlocked string
+			unlocked, campaignErr = g.beatCampaignLevel(s.Map)
+			text += unlocked
+		}
+		text += g.livesLine()
+		text += seedLine(s)
+		endScreen = endScreen.SetText(text)
+		if !g.Endless {
+			// in Endless the next stage has already replaced this one
+			endScreen = endScreen.AddButtons([]string{"Watch Replay"})
+		}
+		if s.Replay != nil && s.Replay.Seed != 0 {
+			endScreen = endScreen.AddButtons([]string{"Replay Seed"})
+		}
+		if g.CampaignMode {
+			endScreen = endScreen.AddButtons([]string{"Campaign"})
+		} else {
+			endScreen = endScreen.AddButtons([]string{"Main Menu"})
+		}
 	} else {
-		text := fmt.Sprintf("STAGE FAILED: %s", s.Map)
-		endScreen = endScreen.SetText(text).AddButtons([]string{"Retry", "Main Menu"})
+		text := fmt.Sprintf("STAGE FAILED: %s\nWall bumps: %d", g.mapTitle(s.Map), s.Collisions)
+		if g.Caught {
+			text += "\nCaught by an enemy!"
+		} else if g.MoveLimit > 0 && g.CurrentSteps >= g.MoveLimit {
+			text += "\nOut of moves!"
+		} else if g.Trapped {
+			text += "\nTrapped!"
+		}
+		text += g.livesLine()
+		text += seedLine(s)
+		if g.outOfLives() {
+			// the run is over, so there's no retrying
+			text += fmt.Sprintf("\n\nGAME OVER\nStages cleared: %d", g.EndlessRounds)
+			endScreen = endScreen.SetText(text).AddButtons([]string{"Show Solution", "Main Menu"})
+		} else {
+			endScreen = endScreen.SetText(text).AddButtons([]string{"Retry", "Show Solution", "Main Menu"})
+		}
 	}
 
+	statsErr := g.Stats.Record(s)
+
 	endScreen = endScreen.SetDoneFunc(func(_ int, id string) {
 		switch id {
 		case "Main Menu":
 			g.ClearGame()
 			g.MainMenu()
+		case "Campaign":
+			g.ClearGame()
+			g.CampaignSelect()
 		case "Retry":
-			g.LoadMaze(g.CurrentMap, g.CurrentMapName)
-			g.PlayMap()
+			g.LoadMaze(g.loadedMap, g.CurrentMapName)
+			g.PlayMap(g.onComplete)
 		case "Continue":
-			return
+			// runEndless has already loaded the next stage
+			g.PlayMap(g.onComplete)
+		case "Watch Replay":
+			g.WatchReplay(s.Replay)
+		case "Replay Seed":
+			g.playSeed(s.Replay)
+		case "Show Solution":
+			g.showSolution()
 		}
 	})
 	g.Pages.AddAndSwitchToPage("end", endScreen, true)
+
+	// show this on top of the end screen so it doesn't get hidden
+	if saveErr != nil {
+		g.DisplayError(saveErr)
+	}
+	if campaignErr != nil {
+		g.DisplayError(campaignErr)
+	}
+	if starsErr != nil {
+		g.DisplayError(starsErr)
+	}
+	if statsErr != nil {
+		g.DisplayError(statsErr)
+	}
 }
 
-// PlayMap loads a map and runs the game on that map.
-func (g *Game) PlayMap() {
-	gameBox := tview.NewTextView().SetText("Press any key to begin...")
-	gameBox.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		failed := false
-		won := false
-		switch event.Key() {
-		case tcell.KeyEscape:
-			g.PauseMenu()
-			return nil
-		case tcell.KeyUp:
-			if g.PlayerY == 0 || g.CurrentMap.Board[g.PlayerY-1][g.PlayerX] == TILE_WALL {
-				failed = true
-			} else {
-				g.PlayerY--
-				g.CurrentSteps++
-				if g.CurrentMap.Board[g.PlayerY][g.PlayerX] == TILE_END {
-					won = true
-				}
+// mapTitle is what the end screen calls the map being played: its title and
+// author if the map file has them, or else just its name.
+func (g *Game) mapTitle(name string) string {
+	if g.CurrentMap == nil || g.CurrentMap.Title == "" {
+		return name
+	}
+	if g.CurrentMap.Author != "" {
+		return fmt.Sprintf("%s by %s", g.CurrentMap.Title, g.CurrentMap.Author)
+	}
+	return g.CurrentMap.Title
+}
+
+// practiceEnd fills in the end screen for a stage played in practice mode.
+// Nothing is recorded, not even the stats, since the solution was there the
+// whole time.
+func (g *Game) practiceEnd(endScreen *tview.Modal, s *Score) {
+	var text string
+	if s.Won {
+		text = fmt.Sprintf("Practice complete: %s\nSteps: %d / Best: %d", g.mapTitle(s.Map), s.Steps, g.CurrentMap.PathLen)
+	} else {
+		text = fmt.Sprintf("Practice failed: %s\nSteps: %d", g.mapTitle(s.Map), s.Steps)
+	}
+	text += seedLine(s)
+	endScreen = endScreen.SetText(text).AddButtons([]string{"Retry", "Main Menu"})
+	endScreen.SetDoneFunc(func(_ int, id string) {
+		switch id {
+		case "Main Menu":
+			g.ClearGame()
+			g.MainMenu()
+		case "Retry":
+			g.LoadMaze(g.loadedMap, g.CurrentMapName)
+			g.PlayMap(g.onComplete)
+		}
+	})
+	g.Pages.AddAndSwitchToPage("end", endScreen, true)
+}
+
+// practicePath is the shortest way from the player to the nearest exit, for
+// practice mode to draw. It's nil if the player can't get out from here.
+func (g *Game) practicePath() map[Coords]bool {
+	player := Coords{X: g.PlayerX, Y: g.PlayerY}
+	exit, _, err := g.CurrentMap.nearestExit(player)
+	if err != nil {
+		return nil
+	}
+	path, err := g.CurrentMap.SolvePath(player, exit)
+	if err != nil {
+		return nil
+	}
+	onPath := make(map[Coords]bool, len(path))
+	for _, c := range path {
+		onPath[c] = true
+	}
+	return onPath
+}
+
+// livesLine is a line for the end screen with how many lives are left in an
+// Endless run, if it has lives at all.
+func (g *Game) livesLine() string {
+	if !g.Endless || g.EndlessConfig.Lives == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nLives left: %d", g.Lives)
+}
+
+// outOfLives reports whether the Endless run is over because every life has
+// been used up.
+func (g *Game) outOfLives() bool {
+	return g.Endless && g.EndlessConfig.Lives > 0 && g.Lives == 0
+}
+
+// seedLine is a line for the end screen with the seed of a generated maze, so
+// it can be played again.
+func seedLine(s *Score) string {
+	if s.Replay == nil || s.Replay.Seed == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nSeed: %d", s.Replay.Seed)
+}
+
+// playSeed stops whatever game is going and plays the maze r was recorded on
+// again, on its own.
+func (g *Game) playSeed(r *Replay) {
+	g.ClearGame()
+	if r.Algorithm == ALGORITHM_DFS {
+		g.PlayGenerated(r.Width, r.Height, r.Seed)
+		return
+	}
+
+	m, err := r.generate()
+	if err != nil {
+		g.DisplayError(err)
+		return
+	}
+	g.LoadMaze(m, fmt.Sprintf("Seed %d", m.Seed))
+	g.Recording.Algorithm = r.Algorithm
+	g.PlayMap(g.EndGame)
+}
+
+// PlayGenerated generates a width by height maze from seed and plays it. A
+// seed of 0 picks a random one.
+func (g *Game) PlayGenerated(width int, height int, seed int64) {
+	var m *Maze
+	var err error
+	if seed == 0 {
+		m, err = GenerateMazeRandom(width, height)
+	} else {
+		m, err = GenerateMaze(width, height, seed)
+	}
+	if err != nil {
+		g.DisplayError(err)
+		return
+	}
+
+	g.LoadMaze(m, fmt.Sprintf("Seed %d", m.Seed))
+	g.PlayMap(g.EndGame)
+}
+
+// PlayLevel loads the level called name from the data folder and plays it.
+func (g *Game) PlayLevel(name string) {
+	if g.LoadFile(name) {
+		g.PlayMap(g.EndGame)
+	}
+}
+
+// recordHighscore saves the score from a won level and returns a line for the
+// end screen saying how it compares to the player's personal best. A new best
+// run becomes the ghost for the level.
+func (g *Game) recordHighscore(s *Score) (string, error) {
+	best, ok := g.Highscores.Best(s.Map)
+	g.Highscores.Record(s.Map, s.Score)
+	err := g.Highscores.Save()
+
+	if !ok || s.Score > best {
+		if s.Replay != nil {
+			g.Ghosts.Runs[s.Map] = s.Replay
+			if ghostErr := g.Ghosts.Save(); err == nil {
+				err = ghostErr
 			}
-		case tcell.KeyDown:
-			if g.PlayerY == g.CurrentMap.Height-1 || g.CurrentMap.Board[g.PlayerY+1][g.PlayerX] == TILE_WALL {
-				failed = true
-			} else {
-				g.PlayerY++
-				g.CurrentSteps++
-				if g.CurrentMap.Board[g.PlayerY][g.PlayerX] == TILE_END {
-					won = true
-				}
+		}
+		return "\nNew personal best!", err
+	}
+	return fmt.Sprintf("\nPersonal best: %d", best), err
+}
+
+// beatCampaignLevel marks a campaign level as beaten and returns a line for
+// the end screen saying which level that unlocked, if any.
+func (g *Game) beatCampaignLevel(mapName string) (string, error) {
+	g.Campaign.Beat(mapName)
+	err := g.Campaign.Save()
+
+	for i, name := range g.AvailMaps {
+		if name == mapName && i+1 < len(g.AvailMaps) {
+			return fmt.Sprintf("\nUnlocked: %s", g.AvailMaps[i+1]), err
+		}
+	}
+	return "\nCampaign complete!", err
+}
+
+// showSolution draws the current map with the shortest path on it. Any key
+// goes back to the end screen.
+func (g *Game) showSolution() {
+	solution, err := g.CurrentMap.DisplaySolution()
+	if err != nil {
+		g.DisplayError(err)
+		return
+	}
+
+	previous, _ := g.Pages.GetFrontPage()
+	view := tview.NewTextView().SetDynamicColors(true).
+		SetText("SOLUTION: " + g.CurrentMapName + "\nPress any key to go back\n\n" + solution)
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		g.Pages.RemovePage("solution")
+		g.Pages.SwitchToPage(previous)
+		return nil
+	})
+	g.Pages.AddAndSwitchToPage("solution", view, true)
+}
+
+// movePlayer tries to move the player by dx and dy, counting a step if it
+// works. Diagonal moves need both of the tiles next to the player in that
+// direction to be open too, so you can't cut corners through walls.
+// Walking onto a key picks it up, and walking into a door uses up a key to
+// open it. Without a key a door is just a wall. On a one-way tile the only
+// move allowed is the way it points.
+// Walking onto ice slides the player along until they hit something or get
+// off the ice. The whole slide only counts as one step.
+func (g *Game) movePlayer(dx int, dy int) (moved bool, won bool) {
+	if !g.canStep(g.PlayerX, g.PlayerY, dx, dy) {
+		return false, false
+	}
+	x := g.PlayerX + dx
+	y := g.PlayerY + dy
+	for g.CurrentMap.Board[y][x] == TILE_ICE && g.canStep(x, y, dx, dy) {
+		x += dx
+		y += dy
+	}
+
+	move := Move{From: Coords{X: g.PlayerX, Y: g.PlayerY}, Keys: g.Keys, Bombs: g.Bombs, Treasure: g.Treasure}
+	switch g.CurrentMap.Board[y][x] {
+	case TILE_KEY:
+		g.Keys++
+		move.Changed, move.Tile = true, TILE_KEY
+		g.CurrentMap.Board[y][x] = TILE_EMPTY
+	case TILE_BOMB:
+		g.Bombs++
+		move.Changed, move.Tile = true, TILE_BOMB
+		g.CurrentMap.Board[y][x] = TILE_EMPTY
+	case TILE_TREASURE:
+		g.Treasure++
+		move.Changed, move.Tile = true, TILE_TREASURE
+		g.CurrentMap.Board[y][x] = TILE_EMPTY
+	case TILE_DOOR:
+		g.Keys--
+		move.Changed, move.Tile = true, TILE_DOOR
+		g.CurrentMap.Board[y][x] = TILE_EMPTY
+	}
+	move.Steps = tileCost(g.CurrentMap.Board[y][x])
+	move.Enemies = append([]Enemy(nil), g.CurrentMap.Enemies...)
+	g.History = append(g.History, move)
+
+	g.PlayerX = x
+	g.PlayerY = y
+	g.CurrentSteps += move.Steps
+
+	// Stepping onto a portal counts as a step like any other tile, but
+	// the trip to the other end of it is free.
+	if pair, ok := g.CurrentMap.Portals[Coords{X: x, Y: y}]; ok {
+		g.PlayerX = pair.X
+		g.PlayerY = pair.Y
+	}
+
+	// the enemies take their step after the player's, and getting caught
+	// beats getting to the end at the same time
+	if g.CurrentMap.stepEnemies(Coords{X: g.PlayerX, Y: g.PlayerY}) {
+		g.Caught = true
+		return true, false
+	}
+	return true, g.CurrentMap.Board[g.PlayerY][g.PlayerX] == TILE_END
+}
+
+// run keeps moving the player by dx and dy until they can't go any further,
+// recording each step like a separate move. It also stops once something
+// happens along the way, like picking something up, going through a portal or
+// sliding on ice, so the player gets a chance to look around.
+func (g *Game) run(dx int, dy int) (moved bool, won bool) {
+	for {
+		from := Coords{X: g.PlayerX, Y: g.PlayerY}
+		stepped, reached := g.movePlayer(dx, dy)
+		if !stepped {
+			return moved, false
+		}
+		moved = true
+		g.Recording.record(dx, dy)
+
+		if reached || g.Caught || (g.MoveLimit > 0 && g.CurrentSteps >= g.MoveLimit) {
+			return true, reached
+		}
+		// going through a portal could send the player round in circles
+		landed := g.PlayerX != from.X+dx || g.PlayerY != from.Y+dy
+		if landed || g.History[len(g.History)-1].Changed {
+			return true, false
+		}
+	}
+}
+
+// TryMove moves the player one step in direction dir, the same as pressing
+// the key for it while playing, and reports whether they moved and whether
+// that got them to the end. It doesn't need the TUI, so it's what tests and
+// bots should use to play. Check stageLost afterwards to see if the stage
+// was lost.
+func (g *Game) TryMove(dir Direction) (moved bool, won bool) {
+	dx, dy := dir.delta()
+	if dx == 0 && dy == 0 {
+		return false, false
+	}
+	return g.tryMove(dx, dy, false)
+}
+
+// tryMove is TryMove for any dx and dy, including diagonals, and it's what
+// the key handler in PlayMap calls so the two can't end up behaving
+// differently. If running is set the player keeps going like run does.
+// Moves that work are recorded for the replay, and ones that don't count as
+// a collision. The clock starts on the first move, but keeping it ticking on
+// screen is up to the caller.
+func (g *Game) tryMove(dx int, dy int, running bool) (moved bool, won bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.FacingX, g.FacingY = dx, dy
+	if running {
+		moved, won = g.run(dx, dy)
+	} else {
+		moved, won = g.movePlayer(dx, dy)
+		if moved {
+			g.Recording.record(dx, dy)
+		}
+	}
+
+	if !moved {
+		g.CurrentCollisions++
+		return false, false
+	}
+	if g.Timed && g.StartTime.IsZero() {
+		g.StartTime = time.Now()
+	}
+	if !won && g.trapped() {
+		g.Trapped = true
+	}
+	return moved, won
+}
+
+// useBomb blows up the wall next to the player in the direction dx, dy if
+// they have a bomb. It goes in the undo history like a move so taking it back
+// puts the wall back. Bombs only go off straight up, down, left or right.
+func (g *Game) useBomb(dx int, dy int) bool {
+	if g.Bombs == 0 || (dx == 0) == (dy == 0) {
+		return false
+	}
+	x := g.PlayerX + dx
+	y := g.PlayerY + dy
+	if target, ok := g.CurrentMap.tileAt(x, y); !ok || target != TILE_WALL {
+		return false
+	}
+
+	g.History = append(g.History, Move{
+		From:    Coords{X: g.PlayerX, Y: g.PlayerY},
+		Keys:    g.Keys,
+		Bombs:   g.Bombs,
+		Blasted: true,
+		Target:  Coords{X: x, Y: y},
+	})
+	g.Bombs--
+	g.CurrentMap.Board[y][x] = TILE_EMPTY
+	return true
+}
+
+// canStep reports whether the player could move by dx and dy from x, y.
+// Anything off the board counts as a wall, so a broken map can't crash the
+// game. Tiles along the edge of the board are like any other though, so an
+// exit on the edge with no wall around it can still be walked onto.
+func (g *Game) canStep(fromX int, fromY int, dx int, dy int) bool {
+	x := fromX + dx
+	y := fromY + dy
+	from, ok := g.CurrentMap.tileAt(fromX, fromY)
+	if !ok {
+		return false
+	}
+	to, ok := g.CurrentMap.tileAt(x, y)
+	if !ok || to == TILE_WALL {
+		return false
+	}
+	if to == TILE_DOOR && g.Keys == 0 {
+		return false
+	}
+	if g.NoBacktrack && g.visited(Coords{X: x, Y: y}) {
+		return false
+	}
+	if wantX, wantY, ok := from.OneWay(); ok && (dx != wantX || dy != wantY) {
+		return false
+	}
+	if dx != 0 && dy != 0 {
+		// doors count as walls here so you can't squeeze past them
+		corner1, ok1 := g.CurrentMap.tileAt(x, fromY)
+		corner2, ok2 := g.CurrentMap.tileAt(fromX, y)
+		if !ok1 || !ok2 || corner1 == TILE_WALL || corner1 == TILE_DOOR || corner2 == TILE_WALL || corner2 == TILE_DOOR {
+			return false
+		}
+	}
+	return true
+}
+
+// undoMove takes back the last move, putting back any key or door it used.
+// It does nothing if the player hasn't moved yet.
+func (g *Game) undoMove() {
+	if len(g.History) == 0 {
+		return
+	}
+
+	last := g.History[len(g.History)-1]
+	g.History = g.History[:len(g.History)-1]
+	g.Bombs = last.Bombs
+	g.Trapped = false
+	if last.Blasted {
+		g.CurrentMap.Board[last.Target.Y][last.Target.X] = TILE_WALL
+		return
+	}
+	g.CurrentSteps -= last.Steps
+	g.CurrentMap.Enemies = last.Enemies
+	g.Caught = false
+	if last.Changed {
+		g.CurrentMap.Board[g.PlayerY][g.PlayerX] = last.Tile
+	}
+	g.PlayerX = last.From.X
+	g.PlayerY = last.From.Y
+	g.Keys = last.Keys
+	g.Treasure = last.Treasure
+}
+
+// PlayState returns where the player is and how many steps they've taken. It's
+// safe to call from any goroutine.
+func (g *Game) PlayState() (x int, y int, steps int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.PlayerX, g.PlayerY, g.CurrentSteps
+}
+
+// trail is every tile the player has stood on, worked out from the undo
+// history. Taking a move back takes it off the trail too.
+func (g *Game) trail() map[Coords]bool {
+	visited := make(map[Coords]bool, len(g.History))
+	for _, move := range g.History {
+		visited[move.From] = true
+	}
+	return visited
+}
+
+// visited reports whether the player has already stood on c and left it.
+// It goes by the undo history like trail does, so taking a move back lets
+// the player go there again.
+func (g *Game) visited(c Coords) bool {
+	for _, move := range g.History {
+		if !move.Blasted && move.From == c {
+			return true
+		}
+	}
+	return false
+}
+
+// stuck reports whether there's nowhere the player can move to. Bombs count
+// as a way out since they can make a new opening.
+func (g *Game) stuck() bool {
+	if g.Bombs > 0 {
+		return false
+	}
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if (dx == 0 && dy == 0) || (dx != 0 && dy != 0 && !g.AllowDiagonal) {
+				continue
 			}
-		case tcell.KeyLeft:
-			if g.PlayerX == 0 || g.CurrentMap.Board[g.PlayerY][g.PlayerX-1] == TILE_WALL {
-				failed = true
-			} else {
-				g.PlayerX--
-				g.CurrentSteps++
-				if g.CurrentMap.Board[g.PlayerY][g.PlayerX] == TILE_END {
-					won = true
+			if g.canStep(g.PlayerX, g.PlayerY, dx, dy) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// backAtStart reports whether the player has walked back to where they
+// started. Before the first step they're there too, but that's not coming
+// back.
+func (g *Game) backAtStart() bool {
+	return g.CurrentSteps > 0 && g.PlayerX == g.CurrentMap.Start.X && g.PlayerY == g.CurrentMap.Start.Y
+}
+
+// stageLost reports whether the last move lost the stage, by getting caught,
+// running out of moves or getting trapped. won is whether that move reached
+// the end, since getting there on the very last move still counts.
+func (g *Game) stageLost(won bool) bool {
+	return g.Caught || (!won && g.MoveLimit > 0 && g.CurrentSteps >= g.MoveLimit) || g.Trapped
+}
+
+// trapped reports whether the player is stuck for good. With no backtracking
+// every move is final, and in hardcore mode trying to move anywhere loses
+// anyway, so being stuck in either of those means the stage is over. Anywhere
+// else the player can still undo their way out.
+func (g *Game) trapped() bool {
+	return (g.NoBacktrack || g.HardcoreMode) && g.stuck()
+}
+
+// hint works out which way the player should go to get to the end as fast as
+// possible. Every hint counts against the score.
+func (g *Game) hint() string {
+	player := Coords{X: g.PlayerX, Y: g.PlayerY}
+	exit, _, err := g.CurrentMap.nearestExit(player)
+	if err != nil {
+		return "No hint available"
+	}
+	path, err := g.CurrentMap.SolvePath(player, exit)
+	if err != nil || len(path) < 2 {
+		return "No hint available"
+	}
+	g.HintsUsed++
+
+	next := path[1]
+	switch {
+	case next.Y < g.PlayerY:
+		return "Hint: go up"
+	case next.Y > g.PlayerY:
+		return "Hint: go down"
+	case next.X < g.PlayerX:
+		return "Hint: go left"
+	default:
+		return "Hint: go right"
+	}
+}
+
+// peek shows the whole maze through the fog for PEEK_DURATION if the
+// player has any peeks left. peeking is PlayMap's flag for whether the fog
+// is lifted, and redraw is called once it comes back. It returns a status
+// message for PlayMap to show.
+func (g *Game) peek(peeking *bool, redraw func()) string {
+	if g.FogRadius == 0 {
+		return "There's no fog to peek through"
+	} else if *peeking {
+		return "You're already peeking"
+	} else if g.PeeksUsed >= g.Peeks {
+		return "No peeks left"
+	}
+
+	g.PeeksUsed++
+	*peeking = true
+	go func() {
+		defer g.Recover()
+		time.Sleep(PEEK_DURATION)
+		g.Application.QueueUpdateDraw(func() {
+			*peeking = false
+			redraw()
+		})
+	}()
+	return fmt.Sprintf("Peeking! %d left", g.Peeks-g.PeeksUsed)
+}
+
+// diagonal returns dx and dy unchanged if diagonal movement is turned on, or
+// no movement at all if it isn't.
+func (g *Game) diagonal(dx int, dy int) (int, int) {
+	if !g.AllowDiagonal {
+		return 0, 0
+	}
+	return dx, dy
+}
+
+// stageScore fills in a Score for the stage that was just played.
+func (g *Game) stageScore(score int, won bool) *Score {
+	return &Score{
+		Score:      score,
+		Won:        won,
+		Map:        g.CurrentMapName,
+		Collisions: g.CurrentCollisions,
+		Hints:      g.HintsUsed,
+		Peeks:      g.PeeksUsed,
+		Steps:      g.CurrentSteps,
+		Treasure:   g.Treasure,
+		Replay:     g.Recording,
+	}
+}
+
+// PlayMap runs the game on the loaded map. onComplete is called with the
+// score once the stage is won or lost, and decides what happens next.
+// Quitting from the pause menu doesn't call it.
+func (g *Game) PlayMap(onComplete func(*Score)) {
+	if _, ok := g.CurrentMap.tileAt(g.PlayerX, g.PlayerY); !ok {
+		g.DisplayError(errors.New("This map can't be played, the start isn't on the board"))
+		return
+	}
+	g.onComplete = onComplete
+
+	gameBox := tview.NewTextView().SetDynamicColors(true)
+	// the minimap only shows up when the board doesn't fit on the screen
+	minimap := tview.NewTextView()
+	minimap.SetBorder(true).SetTitle("Map")
+	layout := tview.NewFlex().AddItem(gameBox, 0, 1, true).AddItem(minimap, 0, 0, false)
+
+	// status is the message shown above the board, and redraw is split out
+	// so the timer can refresh the screen without waiting for a key press
+	status := ""
+	peeking := false
+	blinking := false
+	ghost := g.ghostPath()
+	redraw := func() {
+		var update strings.Builder
+		if g.CurrentMap.PathLen >= 0 {
+			update.WriteString(fmt.Sprintf("Steps: %d / Best: %d", g.CurrentSteps, g.CurrentMap.PathLen))
+		} else {
+			update.WriteString(fmt.Sprintf("Steps: %d / Best: ?", g.CurrentSteps))
+		}
+		if g.MoveLimit > 0 {
+			update.WriteString(fmt.Sprintf("   Moves left: %d", max(g.MoveLimit-g.CurrentSteps, 0)))
+		}
+		if g.Endless {
+			update.WriteString(fmt.Sprintf("   Multiplier: x%.2f", EndlessMultiplier(g.EndlessRounds)))
+		}
+		if g.Endless && g.EndlessConfig.Lives > 0 {
+			update.WriteString(fmt.Sprintf("   Lives: %d", g.Lives))
+		}
+		if g.Bombs > 0 {
+			update.WriteString(fmt.Sprintf("   Bombs: %d", g.Bombs))
+		}
+		if g.Treasure > 0 {
+			update.WriteString(fmt.Sprintf("   Treasure: %d", g.Treasure))
+		}
+		if g.FogRadius > 0 && g.Peeks > 0 {
+			update.WriteString(fmt.Sprintf("   Peeks: %d", g.Peeks-g.PeeksUsed))
+		}
+		if g.Timed {
+			update.WriteString(fmt.Sprintf("   Time: %.1fs", g.elapsed().Seconds()))
+		}
+		if g.Practice {
+			update.WriteString("   PRACTICE")
+		}
+		// anything that happened this move is more important than
+		// where the player ended up
+		note := status
+		if note == "" && g.backAtStart() {
+			note = "Back at start"
+		}
+		update.WriteString("\n" + note + "\n\n")
+
+		// only draw as much of the board as fits under the header, and
+		// keep the player in the middle of it
+		_, _, viewW, viewH := gameBox.GetInnerRect()
+		viewH -= strings.Count(update.String(), "\n")
+		v := g.CurrentMap.fullView()
+		if viewW > 0 && viewH > 0 {
+			v = g.CurrentMap.viewportView(g.PlayerX, g.PlayerY, viewW, viewH)
+		}
+		v.colored = true
+		v.theme = &g.Theme
+		if !peeking {
+			v.fog = g.FogRadius
+		}
+		v.hidePlayer = blinking
+		if len(ghost) > 0 {
+			// the ghost keeps up move for move, and waits at the end
+			// once its run is over
+			at := ghost[min(len(g.Recording.Moves), len(ghost)-1)]
+			v.ghost = &at
+		}
+		// in no backtrack mode the trail is where the player can't go,
+		// so it's always shown
+		if g.ShowTrail || g.NoBacktrack {
+			v.trail = g.trail()
+		}
+		if g.Practice {
+			v.path = g.practicePath()
+			v.faintPath = true
+		}
+
+		update.WriteString(g.CurrentMap.display(v, g.PlayerX, g.PlayerY))
+		gameBox.SetText(update.String())
+
+		// with fog on the minimap would give the maze away
+		fits := viewW <= 0 || viewH <= 0 || (viewW >= g.CurrentMap.Width && viewH >= g.CurrentMap.Height)
+		if fits || g.FogRadius > 0 {
+			layout.ResizeItem(minimap, 0, 0)
+		} else {
+			scale := g.CurrentMap.minimapScale()
+			minimap.SetText(g.CurrentMap.DisplayMinimap(g.PlayerX, g.PlayerY, scale))
+			// the extra 2 is for the border
+			layout.ResizeItem(minimap, (g.CurrentMap.Width+scale-1)/scale+2, 0)
+		}
+	}
+
+	// The part of the board that gets drawn depends on how big gameBox is,
+	// so it has to be worked out again whenever the terminal changes size.
+	// gameBox finds out about that when it's drawn, which is too late for
+	// this frame, so the redraw is queued for straight after it.
+	lastW, lastH := -1, -1
+	gameBox.SetDrawFunc(func(screen tcell.Screen, x int, y int, width int, height int) (int, int, int, int) {
+		if width != lastW || height != lastH {
+			lastW, lastH = width, height
+			g.Application.QueueUpdateDraw(func() {
+				// the stage might be over by the time this runs
+				if g.CurrentMap != nil {
+					redraw()
 				}
+			})
+		}
+		return x, y, width, height
+	})
+
+	// Count down before the stage starts so the player can get a look at
+	// the maze. Pressing a key skips it, and nothing else happens until
+	// it's over, so the clock can't start early either.
+	countdown := COUNTDOWN
+	stopCountdown := make(chan struct{})
+	endCountdown := func() {
+		countdown = 0
+		close(stopCountdown)
+		status = ""
+	}
+	status = fmt.Sprintf("Get ready... %d", countdown)
+	go func() {
+		defer g.Recover()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCountdown:
+				return
+			case <-ticker.C:
+				g.Application.QueueUpdateDraw(func() {
+					// it might have been skipped while this was queued
+					if countdown == 0 {
+						return
+					}
+					countdown--
+					status = fmt.Sprintf("Get ready... %d", countdown)
+					if countdown == 0 {
+						close(stopCountdown)
+						status = "Go!"
+					}
+					redraw()
+				})
 			}
-		case tcell.KeyRight:
-			if g.PlayerX == g.CurrentMap.Width-1 || g.CurrentMap.Board[g.PlayerY][g.PlayerX+1] == TILE_WALL {
-				failed = true
-			} else {
-				g.PlayerX++
-				g.CurrentSteps++
-				if g.CurrentMap.Board[g.PlayerY][g.PlayerX] == TILE_END {
-					won = true
+		}
+	}()
+
+	// Winning makes the player blink on the exit for a moment before the
+	// end screen comes up. winScore is the score waiting to be handed to
+	// onComplete, and it gets cleared as soon as it is so the stage can't be
+	// finished twice, whether the blinking ran out or a key skipped it.
+	var winScore *Score
+	var stopCelebration chan struct{}
+	finishCelebration := func() {
+		s := winScore
+		if s == nil {
+			return
+		}
+		winScore = nil
+		blinking = false
+		close(stopCelebration)
+		onComplete(s)
+	}
+	celebrate := func(s *Score) {
+		if winScore != nil {
+			return
+		}
+		winScore = s
+		stopCelebration = make(chan struct{})
+		stop := stopCelebration
+		status = "You made it!"
+		go func() {
+			defer g.Recover()
+			ticker := time.NewTicker(WIN_BLINK_DELAY)
+			defer ticker.Stop()
+			for ticks := 1; ; ticks++ {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+				}
+				last := ticks == 2*WIN_BLINKS
+				g.Application.QueueUpdateDraw(func() {
+					// it might have been skipped while this was queued
+					if winScore != s {
+						return
+					}
+					if last {
+						finishCelebration()
+						return
+					}
+					blinking = !blinking
+					redraw()
+				})
+				if last {
+					return
 				}
 			}
+		}()
+	}
+
+	gameBox.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if winScore != nil {
+			finishCelebration()
+			return nil
+		}
+		if countdown > 0 {
+			endCountdown()
+			redraw()
+			return nil
 		}
 
-		display, err := g.CurrentMap.DisplayText(g.PlayerX, g.PlayerY)
-		if err != nil {
-			g.DisplayError(err)
+		failed := false
+		won := false
+		hint := ""
+		bombStatus := ""
+		dx, dy := 0, 0
+		km := g.KeyMap
+		switch {
+		case pressed(km.Pause, event):
+			g.stopTimer()
+			g.pauseClock()
+			g.PauseMenu()
 			return nil
+		case pressed(km.Up, event):
+			dy = -1
+		case pressed(km.Down, event):
+			dy = 1
+		case pressed(km.Left, event):
+			dx = -1
+		case pressed(km.Right, event):
+			dx = 1
+		case pressed(km.UpLeft, event):
+			dx, dy = g.diagonal(-1, -1)
+		case pressed(km.UpRight, event):
+			dx, dy = g.diagonal(1, -1)
+		case pressed(km.DownLeft, event):
+			dx, dy = g.diagonal(-1, 1)
+		case pressed(km.DownRight, event):
+			dx, dy = g.diagonal(1, 1)
+		case pressed(km.Undo, event):
+			g.mu.Lock()
+			g.undoMove()
+			g.Recording.undo()
+			g.mu.Unlock()
+		case pressed(km.Hint, event):
+			hint = g.hint()
+		case pressed(km.Trail, event):
+			g.ShowTrail = !g.ShowTrail
+		case pressed(km.Peek, event):
+			hint = g.peek(&peeking, redraw)
+		case pressed(km.Bomb, event):
+			g.mu.Lock()
+			if g.useBomb(g.FacingX, g.FacingY) {
+				g.Recording.recordBomb(g.FacingX, g.FacingY)
+				bombStatus = "Boom!"
+			} else if g.Bombs == 0 {
+				bombStatus = "You don't have any bombs"
+			} else {
+				bombStatus = "There's no wall there to blow up"
+			}
+			g.mu.Unlock()
 		}
 
-		var update strings.Builder
-		if failed {
-			update.WriteString("Can't move there\n\n")
+		if dx != 0 || dy != 0 {
+			var moved bool
+			moved, won = g.tryMove(dx, dy, event.Modifiers()&tcell.ModShift != 0)
+			failed = !moved
+
+			// the clock starts on the first move, not when the map opens
+			if moved && g.Timed {
+				g.startTimer(redraw)
+			}
+		}
+
+		status = ""
+		if g.stageLost(won) {
+			g.stopTimer()
+			onComplete(g.stageScore(0, false))
+		} else if failed && g.HardcoreMode {
+			g.beep(1)
+			g.stopTimer()
+			onComplete(g.stageScore(0, false))
+		} else if failed {
+			g.beep(1)
+			status = "Can't move there"
+		} else if hint != "" {
+			status = hint
+		} else if bombStatus != "" {
+			status = bombStatus
 		} else if won {
+			g.stopTimer()
+			g.beep(2)
 			var score float64
 			if g.Endless {
-				score = CalcScoreEndless(g.CurrentSteps, g.CurrentMap.PathLen, g.EndlessRounds)
+				score = EndlessMultiplier(g.EndlessRounds) * CalcScoreWith(g.EndlessConfig.Score, g.CurrentSteps, g.CurrentMap.PathLen)
+			} else if g.Timed {
+				score = CalcScoreTimed(g.elapsed(), g.CurrentMap.PathLen)
 			} else {
-				score = CalcScore(g.CurrentSteps, g.CurrentMap.PathLen)
+				score = CalcScoreWith(g.ScoreConfig, g.CurrentSteps, g.CurrentMap.PathLen)
 			}
 
-			scorePtr := &Score{
-				Score: int(score),
-				Won:   true,
-				Map:   g.CurrentMapName,
+			if g.Practice {
+				score = 0
 			}
-			//g.ScoreChannel <- scorePtr
-			g.EndGame(scorePtr)
-
-		} else {
-			update.WriteString("\n\n")
+			celebrate(g.stageScore(int(score), true))
 		}
 
-		update.WriteString(display)
-		gameBox.SetText(update.String())
+		redraw()
 		return nil
 	})
 
-	g.Pages.AddAndSwitchToPage("game", gameBox, true)
-
-	//result := <-g.ScoreChannel
-	//g.EndGame(result)
+	redraw()
+	g.Pages.AddAndSwitchToPage("game", layout, true)
 }
 
 // Endless mode keeps randomly generating mazes with more and more difficulty
 // each time. You need to reach the exit within a certin amount of moves each
-// time and your score is based on how many stages you can clear.
+// time and your score is based on how many stages you can clear. Failing a
+// stage costs a life, and the run is over once they're all gone.
 func (g *Game) PlayEndless() {
 	g.Endless = true
-	difficulty := 1
+	g.EndlessRounds = 0
+	g.Lives = g.EndlessConfig.Lives
+	g.ScoreChannel = make(chan *Score)
+	go g.runEndless(g.ScoreChannel, g.EndlessConfig)
+}
+
+// runEndless is the game loop for Endless mode. It runs in its own goroutine
+// because it has to block until each stage's result comes back on the
+// ScoreChannel, and blocking the UI thread would freeze the game. It returns
+// once ClearGame closes the channel.
+func (g *Game) runEndless(scores chan *Score, config EndlessConfig) {
+	defer g.Recover()
+	round := 0
+	lives := config.Lives
+	var cleared *Score
 
 	for {
-		// get dimensions based on difficulty
-		width := 5 + difficulty
-		height := width * 4 / 5
-		m, err := GenerateMaze(width, height, time.Now().UnixNano())
+		width, height := config.Size(round)
+		seed, err := randomSeed()
+		var m *Maze
+		algorithm := config.Algorithm(round)
+		if err == nil {
+			opts := GenerateOptions{Width: width, Height: height, Seed: seed, Algorithm: algorithm}
+			m, err = generateMinPath(opts, config.MinPath(width, height))
+		}
+
+		stage := round
+		lastScore := cleared
+		g.Application.QueueUpdateDraw(func() {
+			// the player might have quit while the maze was generating
+			if g.ScoreChannel != scores {
+				return
+			}
+			if err != nil {
+				g.DisplayError(err)
+				return
+			}
+
+			g.LoadMaze(m, "Endless")
+			g.Recording.Algorithm = algorithm
+			g.EndlessRounds = stage
+			g.MoveLimit = config.MoveLimit(m.PathLen)
+			if lastScore == nil {
+				g.PlayMap(func(s *Score) {
+					scores <- s
+				})
+			} else {
+				// the Continue button starts the stage we just loaded
+				g.EndGame(lastScore)
+			}
+		})
 		if err != nil {
-			g.DisplayError(err)
-			continue
+			return
+		}
+
+		// Wait until the stage is cleared. Failing costs a life and shows
+		// the end screen so the player can retry the same maze, unless
+		// that was the last life.
+		for {
+			score, ok := <-scores
+			if !ok {
+				return
+			}
+			if score.Won {
+				cleared = score
+				break
+			}
+			if config.Lives > 0 {
+				lives--
+			}
+			left := lives
+			g.Application.QueueUpdateDraw(func() {
+				if g.ScoreChannel == scores {
+					g.Lives = left
+					g.EndGame(score)
+				}
+			})
+			if config.Lives > 0 && lives == 0 {
+				return
+			}
 		}
-		g.LoadMaze(m, "Endless")
-		// TODO: the function below doesn't block so it leads to an infinite loop
-		// Endless mode will NOT WORK until it's fixed
-		g.PlayMap()
-		difficulty++
+		round++
 	}
-	g.Endless = false
 }
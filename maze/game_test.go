@@ -0,0 +1,185 @@
+package maze
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHint(t *testing.T) {
+	g := loadGame(t, "#######\n#>....#\n#####.#\n#<....#\n#######\n")
+	want := []struct {
+		dir  Direction
+		hint string
+	}{
+		{POS_X, "Hint: go right"},
+		{POS_X, "Hint: go right"},
+		{POS_X, "Hint: go right"},
+		{POS_X, "Hint: go right"},
+		{POS_Y, "Hint: go down"},
+		{POS_Y, "Hint: go down"},
+		{NEG_X, "Hint: go left"},
+	}
+	for i, step := range want {
+		if got := g.hint(); got != step.hint {
+			t.Fatalf("hint %d: got %q, want %q", i, got, step.hint)
+		}
+		if moved, _ := g.TryMove(step.dir); !moved {
+			t.Fatalf("couldn't follow hint %d", i)
+		}
+	}
+	if g.HintsUsed != len(want) {
+		t.Errorf("HintsUsed is %d, want %d", g.HintsUsed, len(want))
+	}
+
+	// going up needs a maze where the end is above the player
+	g = loadGame(t, "###\n#<#\n#.#\n#>#\n###\n")
+	if got := g.hint(); got != "Hint: go up" {
+		t.Errorf("got %q, want \"Hint: go up\"", got)
+	}
+}
+
+func TestHintWithNoWayOut(t *testing.T) {
+	g := loadGame(t, "#####\n#>#<#\n#####\n")
+	if got := g.hint(); got != "No hint available" {
+		t.Errorf("got %q", got)
+	}
+	if g.HintsUsed != 0 {
+		t.Errorf("a hint that didn't help still counted, HintsUsed is %d", g.HintsUsed)
+	}
+}
+
+// TestConcurrentPlay moves the player about on one goroutine, the way the key
+// handler does, while others read the state and load new stages, the way
+// Endless does. It's meant to be run with go test -race, which fails it if
+// anything touches the play state without holding the lock.
+func TestConcurrentPlay(t *testing.T) {
+	m, err := GenerateMaze(10, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Game{}
+	g.LoadMaze(m, "Endless")
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for i := 0; i < 2000; i++ {
+			g.TryMove(Direction(i % 4))
+		}
+	}()
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			x, y, steps := g.PlayState()
+			if x < 0 || y < 0 || steps < 0 {
+				t.Errorf("read a bad play state: %d, %d after %d steps", x, y, steps)
+				return
+			}
+			g.SaveState(io.Discard)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for seed := int64(2); ; seed++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			next, err := GenerateMaze(10, 10, seed)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			g.LoadMaze(next, "Endless")
+		}
+	}()
+	wg.Wait()
+}
+
+func TestTreasure(t *testing.T) {
+	text := "#######\n#>$.$<#\n#######\n"
+	m, err := LoadMazeFromString(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Treasure) != 2 || m.Treasure[0] != (Coords{X: 2, Y: 1}) || m.Treasure[1] != (Coords{X: 4, Y: 1}) {
+		t.Fatalf("got treasure at %v", m.Treasure)
+	}
+	if m.String() != text {
+		t.Errorf("treasure didn't stay on the board:\n%s", m)
+	}
+
+	g := loadGame(t, text)
+	g.TryMove(POS_X)
+	if g.Treasure != 1 || g.CurrentMap.Board[1][2] != TILE_EMPTY {
+		t.Fatalf("didn't pick up the treasure, have %d:\n%s", g.Treasure, g.CurrentMap)
+	}
+
+	// it can't be picked up twice, and undoing puts it back
+	g.TryMove(NEG_X)
+	g.TryMove(POS_X)
+	if g.Treasure != 1 {
+		t.Errorf("have %d treasure after going back over it, want 1", g.Treasure)
+	}
+	g.mu.Lock()
+	g.undoMove()
+	g.undoMove()
+	g.undoMove()
+	g.mu.Unlock()
+	if g.Treasure != 0 || g.CurrentMap.Board[1][2] != TILE_TREASURE {
+		t.Errorf("undoing left %d treasure:\n%s", g.Treasure, g.CurrentMap)
+	}
+
+	for _, dir := range []Direction{POS_X, POS_X, POS_X, POS_X} {
+		g.TryMove(dir)
+	}
+	if s := g.stageScore(0, true); s.Treasure != 2 {
+		t.Errorf("score has %d treasure, want 2", s.Treasure)
+	}
+}
+
+func TestPracticePath(t *testing.T) {
+	g := loadGame(t, "#######\n#>.k.<#\n#.#####\n#######\n")
+	g.Practice = true
+
+	path := g.practicePath()
+	if len(path) != 5 {
+		t.Fatalf("got a path of %d tiles, want 5", len(path))
+	}
+	// the path follows the player, even if they go the wrong way
+	g.TryMove(POS_Y)
+	if path = g.practicePath(); len(path) != 6 || !path[Coords{X: 1, Y: 2}] {
+		t.Errorf("got path %v from 1, 2", path)
+	}
+	g.TryMove(NEG_Y)
+
+	// it's drawn only over empty tiles, so the key still shows
+	v := g.CurrentMap.fullView()
+	v.path = g.practicePath()
+	v.faintPath = true
+	if got := g.CurrentMap.display(v, g.PlayerX, g.PlayerY); got != "#######\n#@*k*<#\n#.#####\n#######\n" {
+		t.Errorf("got:\n%s", got)
+	}
+	v.colored = true
+	if got := g.CurrentMap.display(v, g.PlayerX, g.PlayerY); strings.Count(got, "::d]*") != 2 {
+		t.Errorf("the path isn't drawn faintly:\n%s", got)
+	}
+
+	g = loadGame(t, "#####\n#>#<#\n#####\n")
+	if path := g.practicePath(); path != nil {
+		t.Errorf("got path %v with no way out", path)
+	}
+}
@@ -1,7 +1,12 @@
 package maze
 
 import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"math/rand"
+	"strconv"
 )
 
 type Direction uint8
@@ -11,34 +16,151 @@ const POS_X Direction = 1
 const NEG_Y Direction = 2
 const NEG_X Direction = 3
 
+// delta is how far a step in direction d moves on the board. POS_Y is down,
+// since rows count down from the top.
+func (d Direction) delta() (dx int, dy int) {
+	switch d {
+	case POS_Y:
+		return 0, 1
+	case POS_X:
+		return 1, 0
+	case NEG_Y:
+		return 0, -1
+	case NEG_X:
+		return -1, 0
+	}
+	return 0, 0
+}
+
+// Algorithm picks which generator Generate uses.
+type Algorithm uint8
+
+const ALGORITHM_DFS Algorithm = 0
+const ALGORITHM_PRIM Algorithm = 1
+const ALGORITHM_WILSON Algorithm = 2
+const ALGORITHM_KRUSKAL Algorithm = 3
+const ALGORITHM_DIVISION Algorithm = 4
+const ALGORITHM_ALDOUS_BRODER Algorithm = 5
+const ALGORITHM_HUNT_AND_KILL Algorithm = 6
+
+// algorithmNames are what each Algorithm is called in config files.
+var algorithmNames = []string{"dfs", "prim", "wilson", "kruskal", "division", "aldous-broder", "hunt-and-kill"}
+
+func (a Algorithm) String() string {
+	if int(a) < len(algorithmNames) {
+		return algorithmNames[a]
+	}
+	return strconv.Itoa(int(a))
+}
+
+// MarshalText writes the algorithm as its name, so config files can say
+// "wilson" instead of 2.
+func (a Algorithm) MarshalText() ([]byte, error) {
+	if int(a) >= len(algorithmNames) {
+		return nil, fmt.Errorf("Unknown maze algorithm: %d", a)
+	}
+	return []byte(algorithmNames[a]), nil
+}
+
+// UnmarshalText reads an algorithm written by MarshalText.
+func (a *Algorithm) UnmarshalText(text []byte) error {
+	for i, name := range algorithmNames {
+		if name == string(text) {
+			*a = Algorithm(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown maze algorithm: %q", text)
+}
+
+// GenerateOptions are everything Generate needs to know to make a maze.
+// Width and Height are in cells like the parameters to GenerateMaze, and
+// Braid works like it does in GenerateBraidedMaze.
+type GenerateOptions struct {
+	Width     int
+	Height    int
+	Seed      int64
+	Algorithm Algorithm
+	Braid     float64
+}
+
+// Generate makes a maze with whichever algorithm opts asks for. It's the same
+// as calling that algorithm's function directly, and then braiding it if
+// Braid is more than 0.
+func Generate(opts GenerateOptions) (*Maze, error) {
+	var m *Maze
+	var err error
+	switch opts.Algorithm {
+	case ALGORITHM_DFS:
+		m, err = GenerateMaze(opts.Width, opts.Height, opts.Seed)
+	case ALGORITHM_PRIM:
+		m, err = GenerateMazePrim(opts.Width, opts.Height, opts.Seed)
+	case ALGORITHM_WILSON:
+		m, err = GenerateMazeWilson(opts.Width, opts.Height, opts.Seed)
+	case ALGORITHM_KRUSKAL:
+		m, err = GenerateMazeKruskal(opts.Width, opts.Height, opts.Seed)
+	case ALGORITHM_DIVISION:
+		m, err = GenerateMazeRecursiveDivision(opts.Width, opts.Height, opts.Seed)
+	case ALGORITHM_ALDOUS_BRODER:
+		m, err = GenerateMazeAldousBroder(opts.Width, opts.Height, opts.Seed)
+	case ALGORITHM_HUNT_AND_KILL:
+		m, err = GenerateMazeHuntAndKill(opts.Width, opts.Height, opts.Seed)
+	default:
+		return nil, fmt.Errorf("Unknown maze algorithm: %d", opts.Algorithm)
+	}
+	if err != nil || opts.Braid <= 0 {
+		return m, err
+	}
+	return braidMaze(m, opts.Width, opts.Height, opts.Seed, opts.Braid)
+}
+
+// MAX_GENERATE_SIZE is the biggest width or height the generators will make a
+// maze with, in cells. Generating is quick now that picking the endpoints
+// uses Diameter (100x100 takes about 15ms, 300x300 about 0.15s), but the
+// board is still held in memory a few times over and a maze that big is no
+// fun to play. This stops a typo or a long Endless run from making something
+// silly. Change it if you really do want bigger mazes.
+var MAX_GENERATE_SIZE = 100
+
+// checkSize makes sure the grid size passed to a generator is sensible. A
+// single cell isn't enough, since the start and end need a cell each.
+func checkSize(width int, height int) error {
+	if width < 1 || height < 1 {
+		return fmt.Errorf("Maze must be at least 1x1, got %dx%d", width, height)
+	}
+	if width*height < 2 {
+		return fmt.Errorf("Maze must have at least 2 cells for the start and end, got %dx%d", width, height)
+	}
+	if width > MAX_GENERATE_SIZE || height > MAX_GENERATE_SIZE {
+		return fmt.Errorf("Maze is too big: %dx%d, the most is %dx%d", width, height, MAX_GENERATE_SIZE, MAX_GENERATE_SIZE)
+	}
+	return nil
+}
+
 // GenerateMaze uses a depth-first approach to generate a maze.
 // The parameters width and height are NOT the dimensions of the resulting map,
 // but rather the dimensions of the maze grid that generates them. The
 // dimension of the generated maze will always be 2n+1.
 func GenerateMaze(width int, height int, seed int64) (*Maze, error) {
-
-	// Start by creating a 2w+1 x 2h+1 board of all walls.
-	// This is to have the cells separated by walls at the end.
-
-	board := make([][]Tile, 0, (2*height + 1))
-	for i := 0; i < (2*height + 1); i++ {
-		board = append(board, make([]Tile, (2*width+1), (2*width+1)))
-		for j, _ := range board[i] {
-			board[i][j] = TILE_WALL
-		}
+	if err := checkSize(width, height); err != nil {
+		return nil, err
 	}
 
+	board := wallBoard(width, height)
+
 	// The caller needs to supply a seed to use the builtin PRNG. If the
-	// user doesn't input one, just read 8 bytes from /dev/urandom or
-	// equivalent.
+	// user doesn't have one, GenerateMazeRandom reads one from
+	// /dev/urandom or equivalent.
 	rng := rand.New(rand.NewSource(seed))
 
-	toVisit := width * height
+	// the first cell is already in the maze, so it counts as visited and
+	// the walk can backtrack all the way to it
+	toVisit := width*height - 1
 	x := rng.Intn(width)
 	y := rng.Intn(height)
-	backtrack := make([]Coords, 0, toVisit)
-	endpoints := make([]Coords, 1)
-	endpoints = append(endpoints, Coords{X: x, Y: y})
+	board[1+2*y][1+2*x] = TILE_EMPTY
+	backtrack := make([]Coords, 0, width*height)
+	backtrack = append(backtrack, Coords{X: x, Y: y})
 
 	for toVisit > 0 {
 		// Randomly traverse board and mark path until a square with no
@@ -60,9 +182,7 @@ func GenerateMaze(width int, height int, seed int64) (*Maze, error) {
 		}
 
 		if len(directions) == 0 {
-			// this is a dead end
-			endpoints = append(endpoints, Coords{X: x, Y: y})
-			// backtrack
+			// this is a dead end, so backtrack
 			for len(directions) == 0 {
 				x = backtrack[len(backtrack)-1].X
 				y = backtrack[len(backtrack)-1].Y
@@ -82,7 +202,7 @@ func GenerateMaze(width int, height int, seed int64) (*Maze, error) {
 				}
 			}
 		} else {
-			move := directions[rand.Intn(len(directions))]
+			move := directions[rng.Intn(len(directions))]
 			switch move {
 			case POS_X:
 				board[2*y+1][2*x+2] = TILE_EMPTY
@@ -104,58 +224,633 @@ func GenerateMaze(width int, height int, seed int64) (*Maze, error) {
 
 	}
 
-	// Place down the entrance and exit
-	// We don't want them to be too close together, but "closeness" in a
-	// maze is really dictated by the distance of the shortest path between
-	// two points and not the actual distance. So, I need a way to find the
-	// two points with the longest "shortest possible path" between them.
+	m, err := placeEndpoints(board, width, height)
+	if err != nil {
+		return nil, err
+	}
+	m.Seed = seed
+	return m, nil
+}
+
+// GenerateMazeRandom works like GenerateMaze, but the seed is read from
+// crypto/rand instead of being passed in. Use the Seed of the result to make
+// the same maze again.
+func GenerateMazeRandom(width int, height int) (*Maze, error) {
+	seed, err := randomSeed()
+	if err != nil {
+		return nil, err
+	}
+	return GenerateMaze(width, height, seed)
+}
 
-	// Because of the way we generate a maze, there *should* be no "loops"
-	// because the algorithm will refuse to visit a space it's already
-	// visited. Therefore, if we pick the start of the generation as a
-	// starting node, then the longest possible shortest path is either
-	// from this point or between two of the ends of the branches.
+// MIN_PATH_RETRIES is how many seeds GenerateMazeMinPath tries before
+// settling for the longest maze it found.
+const MIN_PATH_RETRIES = 100
 
-	// We can use a greedy algorithm.
-	var src Coords
-	var dest Coords
-	dist := -1
+// GenerateMazeMinPath works like GenerateMaze, but if the best path comes out
+// shorter than minPath it tries again with the next seed, and the one after
+// that, until it gets one that's long enough. The Seed of the result is the
+// seed that worked. Some algorithms hardly ever make a path as long as minPath
+// for the size, so after MIN_PATH_RETRIES tries it gives back the longest one
+// it found instead.
+func GenerateMazeMinPath(width int, height int, seed int64, minPath int) (*Maze, error) {
+	return generateMinPath(GenerateOptions{Width: width, Height: height, Seed: seed}, minPath)
+}
 
-	tmpMaze := &Maze{Board: board}
-	for _, p1 := range endpoints {
-		spt, err := tmpMaze.CreateSpt(Coords{p1.X*2 + 1, p1.Y*2 + 1})
+// generateMinPath is GenerateMazeMinPath for any of the options Generate
+// takes.
+func generateMinPath(opts GenerateOptions, minPath int) (*Maze, error) {
+	seed := opts.Seed
+	var longest *Maze
+	for i := 0; i < MIN_PATH_RETRIES; i++ {
+		opts.Seed = seed + int64(i)
+		if opts.Seed == 0 {
+			// 0 means the maze wasn't generated
+			continue
+		}
+		m, err := Generate(opts)
 		if err != nil {
 			return nil, err
 		}
+		if m.PathLen >= minPath {
+			return m, nil
+		}
+		if longest == nil || m.PathLen > longest.PathLen {
+			longest = m
+		}
+	}
+	return longest, nil
+}
+
+// GenerateMazeWithEndpoints works like GenerateMaze, but the start and end go
+// in the cells asked for instead of as far apart as they can be. Like width
+// and height, start and end are in cells, so {0, 0} is the top left corner.
+// Since a replay would make the maze again with GenerateMaze and get the
+// endpoints in the wrong place, the result has no Seed.
+func GenerateMazeWithEndpoints(width int, height int, seed int64, start Coords, end Coords) (*Maze, error) {
+	for _, c := range []Coords{start, end} {
+		if c.X < 0 || c.Y < 0 || c.X >= width || c.Y >= height {
+			return nil, fmt.Errorf("Cell (%d, %d) isn't in a %dx%d maze", c.X, c.Y, width, height)
+		}
+	}
+	if start == end {
+		return nil, errors.New("The start and end can't be the same cell")
+	}
+
+	m, err := GenerateMaze(width, height, seed)
+	if err != nil {
+		return nil, err
+	}
+	m.Board[m.Start.Y][m.Start.X] = TILE_EMPTY
+	m.Board[m.End.Y][m.End.X] = TILE_EMPTY
+
+	m.Start = Coords{X: start.X*2 + 1, Y: start.Y*2 + 1}
+	m.End = Coords{X: end.X*2 + 1, Y: end.Y*2 + 1}
+	m.Exits = []Coords{m.End}
+	m.Board[m.Start.Y][m.Start.X] = TILE_START
+	m.Board[m.End.Y][m.End.X] = TILE_END
+	m.Seed = 0
+
+	err = m.ComputePathLen()
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// randomSeed reads 8 random bytes for a seed. It never returns 0, since a
+// maze with Seed 0 is taken to be one that wasn't generated.
+func randomSeed() (int64, error) {
+	var buf [8]byte
+	for {
+		if _, err := crand.Read(buf[:]); err != nil {
+			return 0, fmt.Errorf("Couldn't read a random seed: %w", err)
+		}
+		if seed := int64(binary.LittleEndian.Uint64(buf[:])); seed != 0 {
+			return seed, nil
+		}
+	}
+}
+
+// GenerateMazePrim generates a maze using a randomized version of Prim's
+// algorithm. Compared to GenerateMaze, the mazes it makes have lots of short
+// branches instead of long winding corridors. The width and height work the
+// same way as in GenerateMaze.
+func GenerateMazePrim(width int, height int, seed int64) (*Maze, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	board := wallBoard(width, height)
+	rng := rand.New(rand.NewSource(seed))
+
+	// The frontier is every unvisited cell that is next to a visited one.
+	// inFrontier is kept alongside it so a cell never gets added twice.
+	inFrontier := make([][]bool, height)
+	for i := range inFrontier {
+		inFrontier[i] = make([]bool, width)
+	}
+	var frontier []Coords
+	visit := func(c Coords) {
+		board[1+2*c.Y][1+2*c.X] = TILE_EMPTY
+		for _, n := range cellNeighbors(c, width, height) {
+			if board[1+2*n.Y][1+2*n.X] != TILE_EMPTY && !inFrontier[n.Y][n.X] {
+				inFrontier[n.Y][n.X] = true
+				frontier = append(frontier, n)
+			}
+		}
+	}
+
+	visit(Coords{X: rng.Intn(width), Y: rng.Intn(height)})
+	for len(frontier) > 0 {
+		// take a random cell out of the frontier
+		i := rng.Intn(len(frontier))
+		cell := frontier[i]
+		frontier[i] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+
+		// and connect it to a random neighbor that's already in the maze
+		var visited []Coords
+		for _, n := range cellNeighbors(cell, width, height) {
+			if board[1+2*n.Y][1+2*n.X] == TILE_EMPTY {
+				visited = append(visited, n)
+			}
+		}
+		n := visited[rng.Intn(len(visited))]
+		board[1+cell.Y+n.Y][1+cell.X+n.X] = TILE_EMPTY
+		visit(cell)
+	}
+
+	m, err := placeEndpoints(board, width, height)
+	if err != nil {
+		return nil, err
+	}
+	m.Seed = seed
+	return m, nil
+}
+
+// GenerateMazeWilson generates a maze using Wilson's algorithm. It does
+// loop-erased random walks from each cell until they run into the maze, which
+// makes every possible maze equally likely instead of favoring long corridors
+// like GenerateMaze does. The width and height work the same way as in
+// GenerateMaze.
+func GenerateMazeWilson(width int, height int, seed int64) (*Maze, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	board := wallBoard(width, height)
+	rng := rand.New(rand.NewSource(seed))
+
+	inMaze := func(c Coords) bool {
+		return board[1+2*c.Y][1+2*c.X] == TILE_EMPTY
+	}
+	board[1+2*rng.Intn(height)][1+2*rng.Intn(width)] = TILE_EMPTY
+
+	// next remembers which way the walk last left each cell. When the walk
+	// loops back on itself this gets overwritten, which is what erases
+	// the loop.
+	next := make([][]Coords, height)
+	for i := range next {
+		next[i] = make([]Coords, width)
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			start := Coords{X: x, Y: y}
+
+			// wander around randomly until we bump into the maze
+			c := start
+			for !inMaze(c) {
+				neighbors := cellNeighbors(c, width, height)
+				n := neighbors[rng.Intn(len(neighbors))]
+				next[c.Y][c.X] = n
+				c = n
+			}
+
+			// then carve out the walk with the loops taken out
+			c = start
+			for !inMaze(c) {
+				n := next[c.Y][c.X]
+				board[1+2*c.Y][1+2*c.X] = TILE_EMPTY
+				board[1+c.Y+n.Y][1+c.X+n.X] = TILE_EMPTY
+				c = n
+			}
+		}
+	}
 
-		longest := -1
-		var p2 Coords
-		for j, line := range spt {
-			for k, val := range line {
-				if val > longest {
-					longest = val
-					p2 = Coords{X: k, Y: j}
+	m, err := placeEndpoints(board, width, height)
+	if err != nil {
+		return nil, err
+	}
+	m.Seed = seed
+	return m, nil
+}
+
+// GenerateMazeAldousBroder generates a maze using the Aldous-Broder
+// algorithm. It wanders around the grid at random and knocks down the wall
+// behind it whenever it walks into a cell it hasn't been to before. Like
+// GenerateMazeWilson every possible maze is equally likely, and it's a lot
+// simpler, but it's also a lot slower since the walk keeps going over cells
+// that are already done while it hunts for the last few. On big grids prefer
+// GenerateMazeWilson. The width and height work the same way as in
+// GenerateMaze.
+func GenerateMazeAldousBroder(width int, height int, seed int64) (*Maze, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	board := wallBoard(width, height)
+	rng := rand.New(rand.NewSource(seed))
+
+	c := Coords{X: rng.Intn(width), Y: rng.Intn(height)}
+	board[1+2*c.Y][1+2*c.X] = TILE_EMPTY
+	for left := width*height - 1; left > 0; {
+		neighbors := cellNeighbors(c, width, height)
+		n := neighbors[rng.Intn(len(neighbors))]
+		if board[1+2*n.Y][1+2*n.X] != TILE_EMPTY {
+			board[1+2*n.Y][1+2*n.X] = TILE_EMPTY
+			board[1+c.Y+n.Y][1+c.X+n.X] = TILE_EMPTY
+			left--
+		}
+		c = n
+	}
+
+	m, err := placeEndpoints(board, width, height)
+	if err != nil {
+		return nil, err
+	}
+	m.Seed = seed
+	return m, nil
+}
+
+// GenerateMazeHuntAndKill generates a maze using the hunt-and-kill algorithm.
+// It carves a random walk until it gets stuck, then hunts through the grid
+// row by row for a cell it hasn't been to that's next to one it has, joins
+// them up and walks on from there. Like GenerateMaze it makes long winding
+// corridors, but since it hunts instead of backtracking it doesn't need a
+// stack as big as the maze. The width and height work the same way as in
+// GenerateMaze.
+func GenerateMazeHuntAndKill(width int, height int, seed int64) (*Maze, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	board := wallBoard(width, height)
+	rng := rand.New(rand.NewSource(seed))
+
+	inMaze := func(c Coords) bool {
+		return board[1+2*c.Y][1+2*c.X] == TILE_EMPTY
+	}
+	carve := func(from Coords, to Coords) {
+		board[1+2*to.Y][1+2*to.X] = TILE_EMPTY
+		board[1+from.Y+to.Y][1+from.X+to.X] = TILE_EMPTY
+	}
+
+	c := Coords{X: rng.Intn(width), Y: rng.Intn(height)}
+	board[1+2*c.Y][1+2*c.X] = TILE_EMPTY
+	// rows above hunted have all been carved already, so each hunt can
+	// start from there
+	hunted := 0
+	for {
+		// walk
+		var unvisited []Coords
+		for _, n := range cellNeighbors(c, width, height) {
+			if !inMaze(n) {
+				unvisited = append(unvisited, n)
+			}
+		}
+		if len(unvisited) > 0 {
+			n := unvisited[rng.Intn(len(unvisited))]
+			carve(c, n)
+			c = n
+			continue
+		}
+
+		// hunt
+		found := false
+		for y := hunted; y < height && !found; y++ {
+			full := true
+			for x := 0; x < width && !found; x++ {
+				cell := Coords{X: x, Y: y}
+				if inMaze(cell) {
+					continue
+				}
+				full = false
+				var visited []Coords
+				for _, n := range cellNeighbors(cell, width, height) {
+					if inMaze(n) {
+						visited = append(visited, n)
+					}
+				}
+				if len(visited) > 0 {
+					carve(visited[rng.Intn(len(visited))], cell)
+					c = cell
+					found = true
 				}
 			}
+			if full && y == hunted {
+				hunted++
+			}
+		}
+		if !found {
+			break
+		}
+	}
+
+	m, err := placeEndpoints(board, width, height)
+	if err != nil {
+		return nil, err
+	}
+	m.Seed = seed
+	return m, nil
+}
+
+// GenerateMazeKruskal generates a maze using randomized Kruskal's algorithm.
+// It goes through every wall between two cells in a random order and knocks
+// it down if the cells on either side aren't connected yet, which gives lots
+// of short dead ends. The width and height work the same way as in
+// GenerateMaze.
+func GenerateMazeKruskal(width int, height int, seed int64) (*Maze, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	board := wallBoard(width, height)
+	rng := rand.New(rand.NewSource(seed))
+
+	// every cell ends up in the maze, the only question is how they join up
+	type wall struct{ a, b Coords }
+	var walls []wall
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			board[1+2*y][1+2*x] = TILE_EMPTY
+			if x != width-1 {
+				walls = append(walls, wall{Coords{X: x, Y: y}, Coords{X: x + 1, Y: y}})
+			}
+			if y != height-1 {
+				walls = append(walls, wall{Coords{X: x, Y: y}, Coords{X: x, Y: y + 1}})
+			}
 		}
+	}
+	rng.Shuffle(len(walls), func(i, j int) {
+		walls[i], walls[j] = walls[j], walls[i]
+	})
 
-		if longest > dist {
-			dist = longest
-			src = p1
-			dest = p2
+	sets := newUnionFind(width * height)
+	for _, w := range walls {
+		if sets.union(w.a.Y*width+w.a.X, w.b.Y*width+w.b.X) {
+			board[1+w.a.Y+w.b.Y][1+w.a.X+w.b.X] = TILE_EMPTY
 		}
+	}
+
+	m, err := placeEndpoints(board, width, height)
+	if err != nil {
+		return nil, err
+	}
+	m.Seed = seed
+	return m, nil
+}
+
+// unionFind keeps track of which cells are connected to each other.
+type unionFind struct {
+	parent []int
+	rank   []int
+}
+
+func newUnionFind(n int) *unionFind {
+	u := &unionFind{parent: make([]int, n), rank: make([]int, n)}
+	for i := range u.parent {
+		u.parent[i] = i
+	}
+	return u
+}
+
+func (u *unionFind) find(i int) int {
+	for u.parent[i] != i {
+		// point at the grandparent as we go so the trees stay flat
+		u.parent[i] = u.parent[u.parent[i]]
+		i = u.parent[i]
+	}
+	return i
+}
 
+// union joins the sets a and b are in. It returns false if they were already
+// in the same set.
+func (u *unionFind) union(a int, b int) bool {
+	rootA, rootB := u.find(a), u.find(b)
+	if rootA == rootB {
+		return false
+	}
+	if u.rank[rootA] < u.rank[rootB] {
+		rootA, rootB = rootB, rootA
 	}
+	u.parent[rootB] = rootA
+	if u.rank[rootA] == u.rank[rootB] {
+		u.rank[rootA]++
+	}
+	return true
+}
 
-	board[src.Y*2+1][src.X*2+1] = TILE_START
-	board[dest.Y*2+1][dest.X*2+1] = TILE_END
+// GenerateMazeRecursiveDivision generates a maze by recursive division.
+// Instead of carving passages out of solid wall like the other generators, it
+// starts with one big open room and splits it in two with a wall that has a
+// single gap in it, then does the same to each half until the rooms are one
+// cell wide. That gives long straight walls and a look of rooms inside rooms.
+// The width and height work the same way as in GenerateMaze.
+func GenerateMazeRecursiveDivision(width int, height int, seed int64) (*Maze, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	board := wallBoard(width, height)
+	for y := 1; y < 2*height; y++ {
+		for x := 1; x < 2*width; x++ {
+			board[y][x] = TILE_EMPTY
+		}
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	// divide splits the room of cells from (x, y) that's w by h cells
+	var divide func(x int, y int, w int, h int)
+	divide = func(x int, y int, w int, h int) {
+		if w < 2 || h < 2 {
+			return
+		}
+
+		// cut across the longer side so the rooms don't get too thin
+		horizontal := h > w || (h == w && rng.Intn(2) == 0)
+		if horizontal {
+			// the wall goes along the top of cell row y+split
+			split := 1 + rng.Intn(h-1)
+			row := 2 * (y + split)
+			for bx := 2 * x; bx <= 2*(x+w); bx++ {
+				board[row][bx] = TILE_WALL
+			}
+			board[row][2*(x+rng.Intn(w))+1] = TILE_EMPTY
+			divide(x, y, w, split)
+			divide(x, y+split, w, h-split)
+		} else {
+			split := 1 + rng.Intn(w-1)
+			col := 2 * (x + split)
+			for by := 2 * y; by <= 2*(y+h); by++ {
+				board[by][col] = TILE_WALL
+			}
+			board[2*(y+rng.Intn(h))+1][col] = TILE_EMPTY
+			divide(x, y, split, h)
+			divide(x+split, y, w-split, h)
+		}
+	}
+	divide(0, 0, width, height)
+
+	// Every wall has exactly one gap, so each split joins its two halves
+	// in one place and there are no loops.
+	m, err := placeEndpoints(board, width, height)
+	if err != nil {
+		return nil, err
+	}
+	m.Seed = seed
+	return m, nil
+}
+
+// GenerateBraidedMaze generates a maze like GenerateMaze and then knocks out
+// walls at dead ends to make loops, so you can't just follow one wall to the
+// exit. braid is the fraction of dead ends that get removed: 0 gives the same
+// kind of perfect maze as GenerateMaze and 1 removes every dead end.
+func GenerateBraidedMaze(width int, height int, seed int64, braid float64) (*Maze, error) {
+	m, err := GenerateMaze(width, height, seed)
+	if err != nil {
+		return nil, err
+	}
+	return braidMaze(m, width, height, seed, braid)
+}
+
+// braidMaze does the wall knocking for GenerateBraidedMaze on a maze that's
+// already been generated, so it works for any of the algorithms.
+func braidMaze(m *Maze, width int, height int, seed int64, braid float64) (*Maze, error) {
+	board := m.Board
+	rng := rand.New(rand.NewSource(seed))
+
+	ends := deadEndCells(board, width, height)
+	rng.Shuffle(len(ends), func(i, j int) {
+		ends[i], ends[j] = ends[j], ends[i]
+	})
+	for _, c := range ends {
+		if rng.Float64() >= braid {
+			continue
+		}
+
+		neighbors := cellNeighbors(c, width, height)
+		var walled []Coords
+		for _, n := range neighbors {
+			if board[1+c.Y+n.Y][1+c.X+n.X] == TILE_WALL {
+				walled = append(walled, n)
+			}
+		}
+		// Opening up an earlier dead end might have already fixed this
+		// one, and a cell with nothing walled off can't be opened up.
+		if len(neighbors)-len(walled) != 1 || len(walled) == 0 {
+			continue
+		}
+		n := walled[rng.Intn(len(walled))]
+		board[1+c.Y+n.Y][1+c.X+n.X] = TILE_EMPTY
+	}
+
+	// The maze has loops now, so the start and end GenerateMaze picked
+	// might not be the furthest apart anymore. Take them off the board and
+	// pick them again.
+	board[m.Start.Y][m.Start.X] = TILE_EMPTY
+	board[m.End.Y][m.End.X] = TILE_EMPTY
+
+	m, err := placeEndpoints(board, width, height)
+	if err != nil {
+		return nil, err
+	}
+	m.Seed = seed
+	return m, nil
+}
+
+// wallBoard creates a 2w+1 x 2h+1 board of all walls. This is to have the
+// cells separated by walls at the end of generation.
+func wallBoard(width int, height int) [][]Tile {
+	board := make([][]Tile, 0, (2*height + 1))
+	for i := 0; i < (2*height + 1); i++ {
+		board = append(board, make([]Tile, (2*width+1), (2*width+1)))
+		for j, _ := range board[i] {
+			board[i][j] = TILE_WALL
+		}
+	}
+	return board
+}
+
+// cellNeighbors returns the cells next to c in generation coordinates,
+// leaving out the ones that would be off the grid.
+func cellNeighbors(c Coords, width int, height int) []Coords {
+	neighbors := make([]Coords, 0, 4)
+	if c.Y != height-1 {
+		neighbors = append(neighbors, Coords{X: c.X, Y: c.Y + 1})
+	}
+	if c.Y != 0 {
+		neighbors = append(neighbors, Coords{X: c.X, Y: c.Y - 1})
+	}
+	if c.X != width-1 {
+		neighbors = append(neighbors, Coords{X: c.X + 1, Y: c.Y})
+	}
+	if c.X != 0 {
+		neighbors = append(neighbors, Coords{X: c.X - 1, Y: c.Y})
+	}
+	return neighbors
+}
+
+// deadEndCells finds every cell of a carved board that only has one opening.
+// These are the ends of the branches, which braidMaze knocks through.
+func deadEndCells(board [][]Tile, width int, height int) []Coords {
+	var ends []Coords
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			openings := 0
+			if board[2*y][2*x+1] == TILE_EMPTY {
+				openings++
+			}
+			if board[2*y+2][2*x+1] == TILE_EMPTY {
+				openings++
+			}
+			if board[2*y+1][2*x] == TILE_EMPTY {
+				openings++
+			}
+			if board[2*y+1][2*x+2] == TILE_EMPTY {
+				openings++
+			}
+			if openings == 1 {
+				ends = append(ends, Coords{X: x, Y: y})
+			}
+		}
+	}
+	return ends
+}
+
+// placeEndpoints takes a fully carved board and puts the start and end as far
+// apart as they can be. "Far" here is the length of the shortest path between
+// them and not how far apart they are on the board, which is what Diameter
+// finds.
+func placeEndpoints(board [][]Tile, width int, height int) (*Maze, error) {
+	m := &Maze{
+		Board:  board,
+		Width:  width*2 + 1,
+		Height: height*2 + 1,
+	}
+	src, dest, dist, err := m.Diameter()
+	if err != nil {
+		return nil, err
+	}
+	// these should never happen, but a broken generator shouldn't get as far
+	// as a maze that can't be played
+	if src == dest {
+		return nil, fmt.Errorf("Generated maze has its start and end on the same tile (%d, %d)", src.X, src.Y)
+	}
+	if board[src.Y][src.X] != TILE_EMPTY || board[dest.Y][dest.X] != TILE_EMPTY {
+		return nil, errors.New("Generated maze has its start or end on a tile that isn't empty")
+	}
 
-	return &Maze{
-		Board:   board,
-		Start:   Coords{X: src.X*2 + 1, Y: src.Y*2 + 1},
-		End:     Coords{X: dest.X*2 + 1, Y: dest.Y*2 + 1},
-		PathLen: dist,
-		Width:   width*2 + 1,
-		Height:  height*2 + 1,
-	}, nil
+	board[src.Y][src.X] = TILE_START
+	board[dest.Y][dest.X] = TILE_END
+	m.Start = src
+	m.End = dest
+	m.Exits = []Coords{dest}
+	m.PathLen = dist
+	return m, nil
 }
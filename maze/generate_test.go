@@ -0,0 +1,295 @@
+package maze
+
+import "testing"
+
+// ALL_ALGORITHMS is every algorithm Generate knows about.
+var ALL_ALGORITHMS = []Algorithm{
+	ALGORITHM_DFS,
+	ALGORITHM_PRIM,
+	ALGORITHM_WILSON,
+	ALGORITHM_KRUSKAL,
+	ALGORITHM_DIVISION,
+	ALGORITHM_ALDOUS_BRODER,
+	ALGORITHM_HUNT_AND_KILL,
+}
+
+// cellsOf returns the size in cells of a generated maze.
+func cellsOf(m *Maze) (width int, height int) {
+	return (m.Width - 1) / 2, (m.Height - 1) / 2
+}
+
+// countPassages counts the gaps in the walls between neighboring cells.
+func countPassages(m *Maze) int {
+	width, height := cellsOf(m)
+	passages := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x != width-1 && m.Board[1+2*y][2+2*x] != TILE_WALL {
+				passages++
+			}
+			if y != height-1 && m.Board[2+2*y][1+2*x] != TILE_WALL {
+				passages++
+			}
+		}
+	}
+	return passages
+}
+
+// reachableCells counts the cells that can be walked to from the cell the
+// start is in.
+func reachableCells(m *Maze) int {
+	width, height := cellsOf(m)
+	start := Coords{X: (m.Start.X - 1) / 2, Y: (m.Start.Y - 1) / 2}
+	seen := map[Coords]bool{start: true}
+	queue := []Coords{start}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, n := range cellNeighbors(c, width, height) {
+			if seen[n] || m.Board[1+c.Y+n.Y][1+c.X+n.X] == TILE_WALL {
+				continue
+			}
+			seen[n] = true
+			queue = append(queue, n)
+		}
+	}
+	return len(seen)
+}
+
+// checkPerfect fails the test unless m is a spanning tree of its cells: every
+// cell can be reached, and there's exactly one way to get between any two.
+func checkPerfect(t *testing.T, m *Maze, name string) {
+	t.Helper()
+	width, height := cellsOf(m)
+	if got := countPassages(m); got != width*height-1 {
+		t.Errorf("%s: %d passages, want %d", name, got, width*height-1)
+	}
+	if got := reachableCells(m); got != width*height {
+		t.Errorf("%s: %d cells reachable from the start, want %d", name, got, width*height)
+	}
+}
+
+func TestGeneratorsMakePerfectMazes(t *testing.T) {
+	sizes := []struct{ width, height int }{
+		{9, 7},
+		{2, 1},
+		{1, 6},
+		{15, 15},
+	}
+	for _, algorithm := range ALL_ALGORITHMS {
+		for _, size := range sizes {
+			for seed := int64(1); seed <= 50; seed++ {
+				m, err := Generate(GenerateOptions{Width: size.width, Height: size.height, Seed: seed, Algorithm: algorithm})
+				if err != nil {
+					t.Fatalf("%v %dx%d seed %d: %v", algorithm, size.width, size.height, seed, err)
+				}
+				checkPerfect(t, m, algorithm.String())
+			}
+		}
+	}
+}
+
+// checkConnected generates 20 mazes with generate and fails the test if any of
+// them has a cell that can't be reached from the start, or if the same seed
+// doesn't make the same maze twice.
+func checkConnected(t *testing.T, generate func(width int, height int, seed int64) (*Maze, error)) {
+	t.Helper()
+	for seed := int64(1); seed <= 20; seed++ {
+		m, err := generate(12, 9, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Width != 25 || m.Height != 19 {
+			t.Fatalf("seed %d: board is %dx%d, want 25x19", seed, m.Width, m.Height)
+		}
+		if got := reachableCells(m); got != 12*9 {
+			t.Errorf("seed %d: %d cells reachable from the start, want %d", seed, got, 12*9)
+		}
+		if m.PathLen <= 0 {
+			t.Errorf("seed %d: path length %d", seed, m.PathLen)
+		}
+
+		again, err := generate(12, 9, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !again.Equal(m) || again.Start != m.Start || again.End != m.End {
+			t.Errorf("seed %d made two different mazes", seed)
+		}
+	}
+}
+
+func TestPrimConnected(t *testing.T) {
+	checkConnected(t, GenerateMazePrim)
+}
+
+// deadEndShare returns what fraction of the cells are dead ends, averaged over
+// seeds 1 to n. The fewer dead ends there are the longer the branches are.
+func deadEndShare(t *testing.T, algorithm Algorithm, n int) float64 {
+	t.Helper()
+	const width, height = 20, 20
+	total := 0
+	for seed := int64(1); seed <= int64(n); seed++ {
+		m, err := Generate(GenerateOptions{Width: width, Height: height, Seed: seed, Algorithm: algorithm})
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += len(deadEndCells(m.Board, width, height))
+	}
+	return float64(total) / float64(n*width*height)
+}
+
+func TestWilsonBranchesDifferFromDFS(t *testing.T) {
+	// a uniform spanning tree has dead ends on a bit under a third of its
+	// cells, while the long corridors dfs makes only have one every ten
+	// or so
+	dfs := deadEndShare(t, ALGORITHM_DFS, 50)
+	wilson := deadEndShare(t, ALGORITHM_WILSON, 50)
+	if dfs > 0.15 {
+		t.Errorf("dfs has dead ends on %.2f of its cells, want under 0.15", dfs)
+	}
+	if wilson < 0.25 || wilson > 0.35 {
+		t.Errorf("wilson has dead ends on %.2f of its cells, want between 0.25 and 0.35", wilson)
+	}
+	if wilson < 2*dfs {
+		t.Errorf("wilson has dead ends on %.2f of its cells and dfs on %.2f, want at least twice as many", wilson, dfs)
+	}
+}
+
+func TestSameSeedSameMaze(t *testing.T) {
+	for _, algorithm := range ALL_ALGORITHMS {
+		opts := GenerateOptions{Width: 15, Height: 12, Seed: 1234, Algorithm: algorithm}
+		first, err := Generate(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			again, err := Generate(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.String() != first.String() {
+				t.Fatalf("%v made a different board on run %d:\n%s\nwant:\n%s", algorithm, i, again, first)
+			}
+		}
+
+		opts.Seed++
+		other, err := Generate(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other.String() == first.String() {
+			t.Errorf("%v made the same board for seeds 1234 and 1235", algorithm)
+		}
+	}
+}
+
+func TestGenerateRejectsBadSizes(t *testing.T) {
+	sizes := []struct{ width, height int }{
+		{0, 5},
+		{5, -1},
+		{1, 1},
+		{MAX_GENERATE_SIZE + 1, 5},
+		{5, MAX_GENERATE_SIZE + 1},
+		{1000000, 1000000},
+	}
+	for _, algorithm := range ALL_ALGORITHMS {
+		for _, size := range sizes {
+			opts := GenerateOptions{Width: size.width, Height: size.height, Seed: 1, Algorithm: algorithm}
+			if _, err := Generate(opts); err == nil {
+				t.Errorf("%v made a %dx%d maze", algorithm, size.width, size.height)
+			}
+		}
+	}
+}
+
+// benchmarkGenerate times making size by size mazes. 200 is past
+// MAX_GENERATE_SIZE, so the limit is raised while it runs.
+func benchmarkGenerate(b *testing.B, size int) {
+	if size > MAX_GENERATE_SIZE {
+		old := MAX_GENERATE_SIZE
+		MAX_GENERATE_SIZE = size
+		defer func() { MAX_GENERATE_SIZE = old }()
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := GenerateMaze(size, size, int64(i+1)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerate50(b *testing.B)  { benchmarkGenerate(b, 50) }
+func BenchmarkGenerate100(b *testing.B) { benchmarkGenerate(b, 100) }
+func BenchmarkGenerate200(b *testing.B) { benchmarkGenerate(b, 200) }
+
+func TestRecursiveDivisionEndpoints(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		m, err := GenerateMazeRecursiveDivision(8, 6, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkPerfect(t, m, "division")
+		for _, c := range []Coords{m.Start, m.End} {
+			if c.X%2 != 1 || c.Y%2 != 1 {
+				t.Errorf("seed %d: endpoint %v isn't on a cell", seed, c)
+			}
+		}
+		if m.Start == m.End || m.Board[m.Start.Y][m.Start.X] != TILE_START || m.Board[m.End.Y][m.End.X] != TILE_END {
+			t.Fatalf("seed %d: bad start %v and end %v", seed, m.Start, m.End)
+		}
+
+		// the endpoints should be as far apart as any two cells are
+		longest := 0
+		for y := 1; y < m.Height; y += 2 {
+			for x := 1; x < m.Width; x += 2 {
+				distances, err := m.DistancesFrom(Coords{X: x, Y: y})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, row := range distances {
+					for _, dist := range row {
+						longest = max(longest, dist)
+					}
+				}
+			}
+		}
+		if m.PathLen != longest {
+			t.Errorf("seed %d: path from start to end is %d, but two cells are %d apart", seed, m.PathLen, longest)
+		}
+	}
+}
+
+func TestGenerateThinMazes(t *testing.T) {
+	for _, algorithm := range ALL_ALGORITHMS {
+		if _, err := Generate(GenerateOptions{Width: 1, Height: 1, Seed: 1, Algorithm: algorithm}); err == nil {
+			t.Errorf("%v made a 1x1 maze", algorithm)
+		}
+
+		for _, size := range []struct{ width, height int }{{1, 2}, {2, 1}, {1, 9}, {9, 1}} {
+			m, err := Generate(GenerateOptions{Width: size.width, Height: size.height, Seed: 1, Algorithm: algorithm})
+			if err != nil {
+				t.Errorf("%v %dx%d: %v", algorithm, size.width, size.height, err)
+				continue
+			}
+			if m.Start == m.End {
+				t.Errorf("%v %dx%d: start and end are both at %v", algorithm, size.width, size.height, m.Start)
+			}
+			if m.Board[m.Start.Y][m.Start.X] != TILE_START || m.Board[m.End.Y][m.End.X] != TILE_END {
+				t.Errorf("%v %dx%d: start or end isn't on the board:\n%s", algorithm, size.width, size.height, m)
+			}
+			// the only way to get across a line of cells is end to end
+			if want := 2 * (size.width*size.height - 1); m.PathLen != want {
+				t.Errorf("%v %dx%d: path is %d, want %d", algorithm, size.width, size.height, m.PathLen, want)
+			}
+		}
+	}
+}
+
+func TestAldousBroderConnected(t *testing.T) {
+	checkConnected(t, GenerateMazeAldousBroder)
+}
+
+func TestHuntAndKillConnected(t *testing.T) {
+	checkConnected(t, GenerateMazeHuntAndKill)
+}
@@ -0,0 +1,93 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// GHOST_GLYPH is what the ghost of the player's best run is drawn as, and
+// GHOST_OVERLAP_GLYPH is drawn instead of the player when they're both on the
+// same tile.
+const GHOST_GLYPH = '○'
+const GHOST_OVERLAP_GLYPH = '◉'
+
+// Ghosts are the replays of the player's best run on each level, so they can
+// race against them. Like Highscores they're saved to a JSON file.
+type Ghosts struct {
+	Path string             `json:"-"`
+	Runs map[string]*Replay `json:"runs"`
+}
+
+// NewGhosts creates an empty Ghosts backed by the file at path. Call Load to
+// read in the runs that are already saved.
+func NewGhosts(path string) *Ghosts {
+	return &Ghosts{Path: path, Runs: make(map[string]*Replay)}
+}
+
+// Load reads the runs from their file. A missing file is fine, it just means
+// no level has been beaten yet.
+func (g *Ghosts) Load() error {
+	content, err := os.ReadFile(g.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(content, g)
+	if err != nil {
+		return fmt.Errorf("Could not read ghosts from %s: %v", g.Path, err)
+	}
+	if g.Runs == nil {
+		g.Runs = make(map[string]*Replay)
+	}
+	return nil
+}
+
+// Save writes the runs to their file, creating its directory if it doesn't
+// exist yet.
+func (g *Ghosts) Save() error {
+	content, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(g.Path), 0755)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(g.Path, content)
+}
+
+// ghostPath plays the ghost of the best run on the current level and returns
+// where it was after each move, starting from where it was before the first.
+// It returns nil if there's no ghost to race, including for generated mazes
+// since they don't get highscores.
+func (g *Game) ghostPath() []Coords {
+	if g.Endless || g.loadedMap == nil || g.loadedMap.Seed != 0 || g.Ghosts == nil {
+		return nil
+	}
+	run, ok := g.Ghosts.Runs[g.CurrentMapName]
+	if !ok || len(run.Moves) == 0 {
+		return nil
+	}
+
+	// the run is played on a game of its own so this one isn't touched
+	sim := &Game{NoBacktrack: g.NoBacktrack}
+	sim.LoadMaze(g.loadedMap, g.CurrentMapName)
+	path := make([]Coords, 0, len(run.Moves)+1)
+	path = append(path, Coords{X: sim.PlayerX, Y: sim.PlayerY})
+	for _, move := range run.Moves {
+		if move.Bomb {
+			sim.useBomb(move.X, move.Y)
+		} else {
+			sim.tryMove(move.X, move.Y, false)
+		}
+		path = append(path, Coords{X: sim.PlayerX, Y: sim.PlayerY})
+	}
+	return path
+}
@@ -0,0 +1,212 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// LEADERBOARD_SIZE is how many scores are kept for each map's leaderboard.
+const LEADERBOARD_SIZE = 10
+
+// Highscores keeps track of the best score the player has gotten on each map,
+// along with a leaderboard of their top few runs. It's backed by a JSON file
+// so the scores stick around between games.
+type Highscores struct {
+	Path   string
+	Scores map[string]int
+	// Top is the leaderboard for each map, best score first
+	Top map[string][]ScoreEntry
+}
+
+// ScoreEntry is one score on a leaderboard and when it was set.
+type ScoreEntry struct {
+	Score int       `json:"score"`
+	Time  time.Time `json:"time"`
+}
+
+// highscoresFile is how Highscores is laid out on disk. Older versions only
+// saved the best scores as a plain map, which Load still reads.
+type highscoresFile struct {
+	Best map[string]int          `json:"best"`
+	Top  map[string][]ScoreEntry `json:"top"`
+}
+
+// dataPath returns where a save file called name should go. Everything the
+// game saves lives in ~/.ap-maze, or in .ap-maze in the working directory if
+// there's no home directory for some reason.
+func dataPath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".ap-maze", name)
+	}
+	return filepath.Join(home, ".ap-maze", name)
+}
+
+// NewHighscores creates an empty Highscores backed by the file at path. Call
+// Load to read in the scores that are already saved.
+func NewHighscores(path string) *Highscores {
+	return &Highscores{
+		Path:   path,
+		Scores: make(map[string]int),
+		Top:    make(map[string][]ScoreEntry),
+	}
+}
+
+// Load reads the scores from the highscores file. A missing file is fine, it
+// just means no level has been beaten yet.
+func (h *Highscores) Load() error {
+	content, err := os.ReadFile(h.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var file highscoresFile
+	if json.Unmarshal(content, &file) == nil && file.Best != nil {
+		h.Scores = file.Best
+		h.Top = file.Top
+		if h.Top == nil {
+			h.Top = make(map[string][]ScoreEntry)
+		}
+		return nil
+	}
+
+	// an old file with just the best scores
+	scores := make(map[string]int)
+	err = json.Unmarshal(content, &scores)
+	if err != nil {
+		return fmt.Errorf("Could not read highscores from %s: %v", h.Path, err)
+	}
+	h.Scores = scores
+	h.Top = make(map[string][]ScoreEntry)
+	return nil
+}
+
+// Save writes the scores to the highscores file, creating its directory if it
+// doesn't exist yet.
+func (h *Highscores) Save() error {
+	content, err := json.MarshalIndent(highscoresFile{Best: h.Scores, Top: h.Top}, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(h.Path), 0755)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(h.Path, content)
+}
+
+// writeFileAtomic writes content to a temporary file next to path and then
+// renames it over path, so a crash halfway through can't leave a half
+// written file behind.
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Record stores score for the map if it beats the previous best, and puts it
+// on the map's leaderboard if it's good enough.
+func (h *Highscores) Record(mapName string, score int) {
+	if best, ok := h.Scores[mapName]; !ok || score > best {
+		h.Scores[mapName] = score
+	}
+
+	top := h.Top[mapName]
+	// ties go after the scores that got there first
+	i := sort.Search(len(top), func(i int) bool { return top[i].Score < score })
+	if i >= LEADERBOARD_SIZE {
+		return
+	}
+	top = append(top, ScoreEntry{})
+	copy(top[i+1:], top[i:])
+	top[i] = ScoreEntry{Score: score, Time: time.Now()}
+	if len(top) > LEADERBOARD_SIZE {
+		top = top[:LEADERBOARD_SIZE]
+	}
+	h.Top[mapName] = top
+}
+
+// TopN returns up to n of the best scores on the map's leaderboard, best
+// first.
+func (h *Highscores) TopN(mapName string, n int) []ScoreEntry {
+	top := h.Top[mapName]
+	if n < len(top) {
+		top = top[:max(n, 0)]
+	}
+	return append([]ScoreEntry(nil), top...)
+}
+
+// Best returns the best score recorded for the map, if there is one.
+func (h *Highscores) Best(mapName string) (int, bool) {
+	best, ok := h.Scores[mapName]
+	return best, ok
+}
+
+func (g *Game) displayHighscores() {
+	var sb strings.Builder
+	sb.WriteString("HIGHSCORES\n\n")
+	for _, name := range g.AvailMaps {
+		if best, ok := g.Highscores.Best(name); ok {
+			sb.WriteString(fmt.Sprintf("%s: %d\n", name, best))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s: ---\n", name))
+		}
+	}
+
+	g.okModal(sb.String(), "highscores")
+}
+
+// showLeaderboard shows the top scores on a level before it's played. Play
+// starts the level and Back goes back to the level select.
+func (g *Game) showLeaderboard(mapName string) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s\n\nLEADERBOARD\n", mapName))
+	top := g.Highscores.TopN(mapName, LEADERBOARD_SIZE)
+	if len(top) == 0 {
+		sb.WriteString("\nNo scores yet, be the first!")
+	}
+	for i, entry := range top {
+		sb.WriteString(fmt.Sprintf("\n%2d. %6d  %s", i+1, entry.Score, entry.Time.Local().Format("2006-01-02 15:04")))
+	}
+
+	modal := tview.NewModal().SetText(sb.String()).AddButtons([]string{"Play", "Back"})
+	modal.SetDoneFunc(func(_ int, label string) {
+		g.Pages.RemovePage("leaderboard")
+		if label == "Play" && g.LoadFile(mapName) {
+			g.PlayMap(g.EndGame)
+		} else {
+			g.LevelSelect()
+		}
+	})
+	g.Pages.AddAndSwitchToPage("leaderboard", modal, false)
+}
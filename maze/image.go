@@ -0,0 +1,56 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// tilePixels is the color each kind of tile is drawn in by WritePNG. Anything
+// that isn't in here (plain paths, mostly) is drawn white.
+var tilePixels = map[Tile]color.RGBA{
+	TILE_WALL:     {0x00, 0x00, 0x00, 0xff},
+	TILE_START:    {0x00, 0xc0, 0x00, 0xff},
+	TILE_END:      {0xe0, 0x00, 0x00, 0xff},
+	TILE_KEY:      {0xff, 0x00, 0xff, 0xff},
+	TILE_DOOR:     {0xff, 0xa5, 0x00, 0xff},
+	TILE_MUD:      {0x80, 0x80, 0x00, 0xff},
+	TILE_ICE:      {0xa0, 0xe0, 0xff, 0xff},
+	TILE_BOMB:     {0x80, 0x00, 0x00, 0xff},
+	TILE_TREASURE: {0xff, 0xd7, 0x00, 0xff},
+}
+
+var portalPixel = color.RGBA{0x00, 0xff, 0xff, 0xff}
+
+// WritePNG draws the board as a PNG image, with each tile drawn as a square
+// cellPx pixels wide. Walls are black, paths are white, the start is green and
+// the end is red. It only looks at Board, so it works for any maze.
+func (m *Maze) WritePNG(w io.Writer, cellPx int) error {
+	if cellPx < 1 {
+		return fmt.Errorf("Invalid cell size: %d", cellPx)
+	}
+	if len(m.Board) == 0 || len(m.Board[0]) == 0 {
+		return errors.New("Cannot draw an empty maze")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, len(m.Board[0])*cellPx, len(m.Board)*cellPx))
+	for i, row := range m.Board {
+		for j, tile := range row {
+			c, ok := tilePixels[tile]
+			if tile.IsPortal() {
+				c = portalPixel
+			} else if !ok {
+				c = color.RGBA{0xff, 0xff, 0xff, 0xff}
+			}
+
+			cell := image.Rect(j*cellPx, i*cellPx, (j+1)*cellPx, (i+1)*cellPx)
+			draw.Draw(img, cell, &image.Uniform{c}, image.Point{}, draw.Src)
+		}
+	}
+
+	return png.Encode(w, img)
+}
@@ -0,0 +1,124 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// jsonMaze is the layout used by LoadMazeFromJSON and MarshalJSON. It's the
+// format the web level editor uses, with every tile stored as its own string.
+type jsonMaze struct {
+	Width  int        `json:"width"`
+	Height int        `json:"height"`
+	Tiles  [][]string `json:"tiles"`
+	Start  *Coords    `json:"start,omitempty"`
+	End    *Coords    `json:"end,omitempty"`
+	Title  string     `json:"title,omitempty"`
+	Author string     `json:"author,omitempty"`
+}
+
+// LoadMazeFromJSON loads a maze from the JSON format used by MarshalJSON.
+// The start and end fields are optional, but if they are given they have to
+// match where the start and end tiles actually are.
+func LoadMazeFromJSON(data []byte) (*Maze, error) {
+	var jm jsonMaze
+	err := json.Unmarshal(data, &jm)
+	if err != nil {
+		return nil, err
+	}
+
+	if jm.Width <= 0 || jm.Height <= 0 {
+		return nil, fmt.Errorf("Invalid maze dimensions: %dx%d", jm.Width, jm.Height)
+	}
+	if len(jm.Tiles) != jm.Height {
+		return nil, fmt.Errorf("Maze must have as many rows as its height. Expected height: %d Got height: %d", jm.Height, len(jm.Tiles))
+	}
+
+	// Put each row back together the way it'd be in a maze file, so it goes
+	// through exactly the same validation. It skips the metadata, since a
+	// first row of "#" then "!" is a bad tile here and not a comment.
+	lines := make([]string, len(jm.Tiles))
+	for i, row := range jm.Tiles {
+		if len(row) != jm.Width {
+			return nil, fmt.Errorf("All rows in a maze must have the same length. Expected width: %d Got width: %d", jm.Width, len(row))
+		}
+		var sb strings.Builder
+		for _, tile := range row {
+			if len([]rune(tile)) != 1 || tile == "\n" {
+				return nil, fmt.Errorf("Invalid maze tile: %q", tile)
+			}
+			sb.WriteString(tile)
+		}
+		lines[i] = sb.String()
+	}
+
+	m, err := loadBoard(lines, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Board[m.Start.Y][m.Start.X] != TILE_START {
+		return nil, errors.New("Maze must have a start point")
+	}
+	if m.Board[m.End.Y][m.End.X] != TILE_END {
+		return nil, errors.New("Maze must have an end point")
+	}
+	if jm.Start != nil && *jm.Start != m.Start {
+		return nil, fmt.Errorf("Start point (%d, %d) does not match the start tile at (%d, %d)", jm.Start.X, jm.Start.Y, m.Start.X, m.Start.Y)
+	}
+	if jm.End != nil && !m.isExit(*jm.End) {
+		return nil, fmt.Errorf("End point (%d, %d) is not on an end tile", jm.End.X, jm.End.Y)
+	}
+
+	m.Title = jm.Title
+	m.Author = jm.Author
+	return m, nil
+}
+
+// MarshalJSON encodes the maze in the format LoadMazeFromJSON reads, so a
+// maze can be passed back and forth with the level editor. Enemies go back on
+// the board where they are, like String does.
+func (m *Maze) MarshalJSON() ([]byte, error) {
+	enemies := m.enemyTiles()
+	tiles := make([][]string, len(m.Board))
+	for i, row := range m.Board {
+		tiles[i] = make([]string, len(row))
+		for j, tile := range row {
+			if enemy, ok := enemies[Coords{X: j, Y: i}]; ok {
+				tile = enemy
+			}
+			tiles[i][j] = string(tile)
+		}
+	}
+
+	return json.Marshal(jsonMaze{
+		Width:  m.Width,
+		Height: m.Height,
+		Tiles:  tiles,
+		Start:  &m.Start,
+		End:    &m.End,
+		Title:  m.Title,
+		Author: m.Author,
+	})
+}
+
+// jsonSolution is the layout used by SolutionJSON.
+type jsonSolution struct {
+	Maze *Maze    `json:"maze"`
+	Path []Coords `json:"path"`
+}
+
+// SolutionJSON encodes the maze along with the shortest path from src to
+// dest, for drawing it somewhere else. The maze is in the same format as
+// MarshalJSON and the path is every tile along the way, starting with src and
+// ending with dest. If there's no way from src to dest it returns an error
+// and nothing else.
+func (m *Maze) SolutionJSON(src Coords, dest Coords) ([]byte, error) {
+	path, err := m.SolvePath(src, dest)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonSolution{Maze: m, Path: path})
+}
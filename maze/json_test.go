@@ -0,0 +1,89 @@
+package maze
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	m, err := LoadMazeFromString("#! title: Patrol\n#! author: Daniel\n#######\n#>.E.<#\n#.###.#\n#..N..#\n#######\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := m.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadMazeFromJSON(data)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+
+	if !loaded.Equal(m) {
+		t.Errorf("board changed going through JSON:\n%s\nwant:\n%s", loaded, m)
+	}
+	if len(loaded.Enemies) != 2 {
+		t.Errorf("got %d enemies, want 2", len(loaded.Enemies))
+	}
+	if loaded.String() != m.String() {
+		t.Errorf("got:\n%s\nwant:\n%s", loaded, m)
+	}
+	if loaded.Title != "Patrol" || loaded.Author != "Daniel" {
+		t.Errorf("got title %q and author %q", loaded.Title, loaded.Author)
+	}
+}
+
+func TestSolutionJSON(t *testing.T) {
+	m, err := LoadMazeFromString("#####\n#>..#\n###<#\n#####\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := m.SolutionJSON(m.Start, m.End)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var solution struct {
+		Maze json.RawMessage `json:"maze"`
+		Path []Coords        `json:"path"`
+	}
+	if err := json.Unmarshal(data, &solution); err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+	want := []Coords{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}, {X: 3, Y: 2}}
+	if len(solution.Path) != len(want) {
+		t.Fatalf("got path %v, want %v", solution.Path, want)
+	}
+	for i := range want {
+		if solution.Path[i] != want[i] {
+			t.Fatalf("got path %v, want %v", solution.Path, want)
+		}
+	}
+	loaded, err := LoadMazeFromJSON(solution.Maze)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(m) {
+		t.Errorf("got maze:\n%s\nwant:\n%s", loaded, m)
+	}
+
+	m, err = LoadMazeFromString("#####\n#>#<#\n#####\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := m.SolutionJSON(m.Start, m.End); err == nil || data != nil {
+		t.Errorf("got %s and error %v for a maze with no way through", data, err)
+	}
+}
+
+func TestLoadMazeFromJSONNoMetadata(t *testing.T) {
+	// the first row reads as "#!#####" as text, but it's still a row here
+	data := `{"width": 7, "height": 4, "tiles": [
+		["#", "!", "#", "#", "#", "#", "#"],
+		["#", "#", "#", "#", "#", "#", "#"],
+		["#", ">", ".", ".", ".", "<", "#"],
+		["#", "#", "#", "#", "#", "#", "#"]]}`
+	if m, err := LoadMazeFromJSON([]byte(data)); err == nil {
+		t.Errorf("loaded a maze with a bad tile in its first row:\n%s", m)
+	}
+}
@@ -0,0 +1,91 @@
+package maze
+
+import (
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+// KeyBinding is a single key that can trigger an action. Rune is only looked
+// at when Key is tcell.KeyRune, i.e. for normal letter and number keys.
+type KeyBinding struct {
+	Key  tcell.Key
+	Rune rune
+}
+
+// KeyMap is the set of keys bound to each action during play. An action can
+// have as many keys as you want, or none at all to turn it off.
+type KeyMap struct {
+	Up        []KeyBinding
+	Down      []KeyBinding
+	Left      []KeyBinding
+	Right     []KeyBinding
+	UpLeft    []KeyBinding
+	UpRight   []KeyBinding
+	DownLeft  []KeyBinding
+	DownRight []KeyBinding
+	Undo      []KeyBinding
+	Hint      []KeyBinding
+	Trail     []KeyBinding
+	Bomb      []KeyBinding
+	Peek      []KeyBinding
+	Pause     []KeyBinding
+}
+
+// runeKeys makes a binding for each of the given characters.
+func runeKeys(runes ...rune) []KeyBinding {
+	bindings := make([]KeyBinding, 0, len(runes))
+	for _, r := range runes {
+		bindings = append(bindings, KeyBinding{Key: tcell.KeyRune, Rune: r})
+	}
+	return bindings
+}
+
+// specialKeys makes a binding for each of the given non-character keys.
+func specialKeys(keys ...tcell.Key) []KeyBinding {
+	bindings := make([]KeyBinding, 0, len(keys))
+	for _, k := range keys {
+		bindings = append(bindings, KeyBinding{Key: k})
+	}
+	return bindings
+}
+
+// DefaultKeyMap returns the standard controls: arrow keys, WASD and vim keys
+// to move, Q/E/Z/C and the numpad for diagonals, Backspace to undo, ? for a
+// hint, T to show the trail, space to use a bomb, P to peek through the fog
+// and ESC to pause.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up:    append(specialKeys(tcell.KeyUp), runeKeys('w', 'W', 'k')...),
+		Down:  append(specialKeys(tcell.KeyDown), runeKeys('s', 'S', 'j')...),
+		Left:  append(specialKeys(tcell.KeyLeft), runeKeys('a', 'A', 'h')...),
+		Right: append(specialKeys(tcell.KeyRight), runeKeys('d', 'D', 'l')...),
+		// with num lock off, the numpad corners send Home, PgUp, etc.
+		UpLeft:    append(specialKeys(tcell.KeyHome), runeKeys('q', 'Q', '7')...),
+		UpRight:   append(specialKeys(tcell.KeyPgUp), runeKeys('e', 'E', '9')...),
+		DownLeft:  append(specialKeys(tcell.KeyEnd), runeKeys('z', 'Z', '1')...),
+		DownRight: append(specialKeys(tcell.KeyPgDn), runeKeys('c', 'C', '3')...),
+		Undo:      specialKeys(tcell.KeyBackspace, tcell.KeyBackspace2),
+		Hint:      runeKeys('?'),
+		Trail:     runeKeys('t', 'T'),
+		Bomb:      runeKeys(' '),
+		Peek:      runeKeys('p', 'P'),
+		Pause:     specialKeys(tcell.KeyEscape),
+	}
+}
+
+// SetKeyMap changes the controls used by PlayMap.
+func (g *Game) SetKeyMap(km KeyMap) {
+	g.KeyMap = km
+}
+
+// pressed reports whether the key event matches any of the bindings.
+func pressed(bindings []KeyBinding, event *tcell.EventKey) bool {
+	for _, b := range bindings {
+		if event.Key() != b.Key {
+			continue
+		}
+		if b.Key != tcell.KeyRune || event.Rune() == b.Rune {
+			return true
+		}
+	}
+	return false
+}
@@ -13,24 +13,157 @@ const TILE_EMPTY Tile = '.'
 const TILE_WALL Tile = '#'
 const TILE_START Tile = '>'
 const TILE_END Tile = '<'
+const TILE_KEY Tile = 'k'
+const TILE_DOOR Tile = 'D'
+const TILE_MUD Tile = 'm'
+
+// Picking up a bomb lets you blow up one wall.
+const TILE_BOMB Tile = 'b'
+
+// Treasure is worth TREASURE_BONUS extra points if the stage is won, so it
+// can be worth going out of the way for.
+const TILE_TREASURE Tile = '$'
+
+// Walking onto ice makes you slide the same way until something stops you.
+const TILE_ICE Tile = 'i'
+
+// One-way tiles can be walked onto from any side, but once you're on one you
+// can only leave in the direction it points. They can't use < and > since
+// those are the start and end.
+const TILE_ONEWAY_UP Tile = '^'
+const TILE_ONEWAY_DOWN Tile = 'v'
+const TILE_ONEWAY_LEFT Tile = '{'
+const TILE_ONEWAY_RIGHT Tile = '}'
+
+// OneWay returns the only direction the player can leave a one-way tile in.
+// ok is false if the tile isn't one-way.
+func (t Tile) OneWay() (dx int, dy int, ok bool) {
+	switch t {
+	case TILE_ONEWAY_UP:
+		return 0, -1, true
+	case TILE_ONEWAY_DOWN:
+		return 0, 1, true
+	case TILE_ONEWAY_LEFT:
+		return -1, 0, true
+	case TILE_ONEWAY_RIGHT:
+		return 1, 0, true
+	}
+	return 0, 0, false
+}
+
+// Portals are the digits from TILE_PORTAL_FIRST to TILE_PORTAL_LAST. Each
+// digit has to appear exactly twice, and walking onto one of them takes you
+// to the other.
+const TILE_PORTAL_FIRST Tile = '0'
+const TILE_PORTAL_LAST Tile = '9'
+
+// IsPortal reports whether the tile is one of the portal digits.
+func (t Tile) IsPortal() bool {
+	return t >= TILE_PORTAL_FIRST && t <= TILE_PORTAL_LAST
+}
 
 type Coords struct {
-	X int
-	Y int
+	X int `json:"x"`
+	Y int `json:"y"`
 }
 
 type Maze struct {
-	Board   [][]Tile
-	Start   Coords
+	Board [][]Tile
+	Start Coords
+	// End is the first exit. Reaching any of the Exits wins.
 	End     Coords
+	Exits   []Coords
 	PathLen int
 	Width   int
 	Height  int
+	// Portals maps the position of each portal to the one it's paired with
+	Portals map[Coords]Coords
+	// Seed is the seed a generated maze was made from, or 0 for a map loaded
+	// from a file
+	Seed int64
+	// Enemies are where the enemies are right now. Most mazes don't have
+	// any.
+	Enemies []Enemy
+	// Treasure is where the treasure was when the maze was loaded
+	Treasure []Coords
+	// Title and Author come from the metadata at the top of a maze file,
+	// and are empty if it doesn't have any
+	Title  string
+	Author string
+}
+
+// known reports whether the tile is one the loader accepts.
+func (t Tile) known() bool {
+	switch t {
+	case TILE_EMPTY, TILE_WALL, TILE_START, TILE_END, TILE_KEY, TILE_DOOR, TILE_MUD, TILE_ICE, TILE_BOMB,
+		TILE_TREASURE, TILE_ENEMY_HORIZONTAL, TILE_ENEMY_VERTICAL:
+		return true
+	}
+	_, _, oneWay := t.OneWay()
+	return oneWay || t.IsPortal()
 }
 
+// METADATA_PREFIX starts a line of metadata at the top of a maze file, like
+//
+//	#! title: The Long Way Round
+//	#! author: Daniel Ha
+//
+// Any other line starting with it is a comment. A wall on its own can't
+// start with it, since ! isn't a tile.
+const METADATA_PREFIX = "#!"
+
+// LoadMazeFromString reads a maze drawn in ASCII, one row per line. Every
+// line has to be the same width, and the only blank line allowed is the one
+// after a final newline. Windows line endings are fine too. Metadata lines
+// can go before the first row, see METADATA_PREFIX.
 func LoadMazeFromString(s string) (*Maze, error) {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil, errors.New("Maze is empty")
+	}
 	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		l = strings.TrimSuffix(l, "\r")
+		lines[i] = l
+		// tabs would be a confusing "invalid tile" error otherwise
+		if strings.ContainsRune(l, '\t') {
+			return nil, fmt.Errorf("Line %d has a tab in it, use . or spaces for empty tiles", i+1)
+		}
+	}
+
+	// the board starts after the metadata, but errors still give the line
+	// number in the file
+	var title, author string
+	offset := 0
+	for offset < len(lines) && strings.HasPrefix(lines[offset], METADATA_PREFIX) {
+		key, value, ok := strings.Cut(strings.TrimPrefix(lines[offset], METADATA_PREFIX), ":")
+		if ok {
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "title":
+				title = strings.TrimSpace(value)
+			case "author":
+				author = strings.TrimSpace(value)
+			}
+		}
+		offset++
+	}
+	if offset == len(lines) {
+		return nil, errors.New("Maze is empty")
+	}
+
+	m, err := loadBoard(lines[offset:], offset)
+	if err != nil {
+		return nil, err
+	}
+	m.Title = title
+	m.Author = author
+	return m, nil
+}
 
+// loadBoard builds a maze from its rows, without any metadata in front. The
+// rows are numbered from offset+1 in errors, to match the line in the file
+// they came from.
+func loadBoard(lines []string, offset int) (*Maze, error) {
 	var board [][]Tile
 	var startX int
 	var startY int
@@ -38,17 +171,19 @@ func LoadMazeFromString(s string) (*Maze, error) {
 	var endY int
 
 	starts := 0
-	ends := 0
-	width := -1
+	var exits []Coords
+	width := len([]Tile(lines[0]))
+	if width == 0 {
+		return nil, fmt.Errorf("Maze has zero width: line %d is empty", offset+1)
+	}
+	portals := make(map[Tile][]Coords)
+	var enemies []Enemy
+	var treasure []Coords
 	for i, l := range lines {
 		row := []Tile(l)
 
-		if len(row) == 0 {
-			continue
-		} else if width == -1 {
-			width = len(row)
-		} else if width != len(row) {
-			return nil, fmt.Errorf("All rows in a maze must have the same length. Expected width: %d Got width: %d", width, len(row))
+		if width != len(row) {
+			return nil, fmt.Errorf("All rows in a maze must have the same length. Line %d is %d wide but line %d is %d wide", offset+1, width, offset+i+1, len(row))
 		}
 
 		for j, tile := range row {
@@ -60,31 +195,143 @@ func LoadMazeFromString(s string) (*Maze, error) {
 				startY = i
 				starts++
 			} else if tile == TILE_END {
-				if ends > 0 {
-					return nil, errors.New("Maze cannot have multiple end points")
+				if len(exits) == 0 {
+					endX = j
+					endY = i
 				}
-				endX = j
-				endY = i
-				ends++
+				exits = append(exits, Coords{X: j, Y: i})
 			} else if rune(tile) == ' ' {
 				row[j] = TILE_EMPTY
-			} else if tile != TILE_EMPTY && tile != TILE_WALL {
-				return nil, fmt.Errorf("Invalid maze tile: %c", tile)
+			} else if tile == TILE_TREASURE {
+				treasure = append(treasure, Coords{X: j, Y: i})
+			} else if tile.IsPortal() {
+				portals[tile] = append(portals[tile], Coords{X: j, Y: i})
+			} else if enemy, ok := newEnemy(tile, Coords{X: j, Y: i}); ok {
+				enemies = append(enemies, enemy)
+				row[j] = TILE_EMPTY
+			} else if !tile.known() {
+				return nil, fmt.Errorf("Invalid maze tile on line %d: %c", offset+i+1, tile)
 			}
 		}
 		board = append(board, row)
 	}
 
+	pairs := make(map[Coords]Coords)
+	for digit, spots := range portals {
+		if len(spots) != 2 {
+			return nil, fmt.Errorf("Portal %c must appear exactly twice, found it %d times", digit, len(spots))
+		}
+		pairs[spots[0]] = spots[1]
+		pairs[spots[1]] = spots[0]
+	}
+
 	return &Maze{
-		Start:   Coords{X: startX, Y: startY},
-		End:     Coords{X: endX, Y: endY},
-		Board:   board,
-		PathLen: -1,
-		Height:  len(board),
-		Width:   width,
+		Start:    Coords{X: startX, Y: startY},
+		End:      Coords{X: endX, Y: endY},
+		Exits:    exits,
+		Board:    board,
+		PathLen:  -1,
+		Height:   len(board),
+		Width:    width,
+		Portals:  pairs,
+		Enemies:  enemies,
+		Treasure: treasure,
 	}, nil
 }
 
+// Clone makes a copy of the maze that can be changed without touching the
+// original, like when a key gets picked up or a wall gets blown up.
+func (m *Maze) Clone() *Maze {
+	c := *m
+	c.Board = make([][]Tile, len(m.Board))
+	for i, row := range m.Board {
+		c.Board[i] = append([]Tile(nil), row...)
+	}
+	c.Exits = append([]Coords(nil), m.Exits...)
+	c.Enemies = append([]Enemy(nil), m.Enemies...)
+	c.Treasure = append([]Coords(nil), m.Treasure...)
+	if m.Portals != nil {
+		c.Portals = make(map[Coords]Coords, len(m.Portals))
+		for from, to := range m.Portals {
+			c.Portals[from] = to
+		}
+	}
+	return &c
+}
+
+// Equal reports whether two mazes have the same board, start, end and size.
+// A space counts as an empty tile, the same as when a maze gets loaded. The
+// rest, like the seed, PathLen and where the enemies are, doesn't count, so
+// a map file and the generated maze it was saved from come out equal.
+func (m *Maze) Equal(other *Maze) bool {
+	if m == nil || other == nil {
+		return m == other
+	}
+	if m.Width != other.Width || m.Height != other.Height || m.Start != other.Start || m.End != other.End {
+		return false
+	}
+	if len(m.Board) != len(other.Board) {
+		return false
+	}
+	normalize := func(t Tile) Tile {
+		if rune(t) == ' ' {
+			return TILE_EMPTY
+		}
+		return t
+	}
+	for i, row := range m.Board {
+		if len(row) != len(other.Board[i]) {
+			return false
+		}
+		for j, tile := range row {
+			if normalize(tile) != normalize(other.Board[i][j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String writes the maze back out in the format LoadMazeFromString reads, so
+// loading the result gives an Equal maze. Enemies go back on the board where
+// they are now, but facing the way a new one would.
+func (m *Maze) String() string {
+	var sb strings.Builder
+	if m.Title != "" {
+		sb.WriteString(fmt.Sprintf("%s title: %s\n", METADATA_PREFIX, m.Title))
+	}
+	if m.Author != "" {
+		sb.WriteString(fmt.Sprintf("%s author: %s\n", METADATA_PREFIX, m.Author))
+	}
+
+	enemies := m.enemyTiles()
+	for i, row := range m.Board {
+		for j, tile := range row {
+			if enemy, ok := enemies[Coords{X: j, Y: i}]; ok {
+				tile = enemy
+			}
+			sb.WriteRune(rune(tile))
+		}
+		sb.WriteRune('\n')
+	}
+	return sb.String()
+}
+
+// enemyTiles is the tile each enemy was loaded from, by where it is now.
+// The loader takes enemies off the board, so anything writing a maze back out
+// has to put them back.
+func (m *Maze) enemyTiles() map[Coords]Tile {
+	enemies := make(map[Coords]Tile, len(m.Enemies))
+	for _, e := range m.Enemies {
+		if e.DX != 0 {
+			enemies[e.Pos] = TILE_ENEMY_HORIZONTAL
+		} else {
+			enemies[e.Pos] = TILE_ENEMY_VERTICAL
+		}
+	}
+	return enemies
+}
+
 func LoadMazeFromFile(filename string) (*Maze, error) {
 	content, err := os.ReadFile(filename)
 	if err != nil {
@@ -95,11 +342,172 @@ func LoadMazeFromFile(filename string) (*Maze, error) {
 }
 
 func (m *Maze) DisplayText(playerX int, playerY int) (string, error) {
+	return m.display(m.fullView(), playerX, playerY), nil
+}
+
+// DisplayColored works like DisplayText, but it wraps the tiles in tview's
+// color tags. Whatever shows the result needs to have dynamic colors turned on.
+func (m *Maze) DisplayColored(playerX int, playerY int) (string, error) {
+	v := m.fullView()
+	v.colored = true
+	return m.display(v, playerX, playerY), nil
+}
+
+// DisplayViewport works like DisplayText but only shows a viewW by viewH
+// window of the board centered on the player, so big mazes still fit on the
+// screen. Near the edges of the board the window stops following the player
+// so it never goes past them.
+func (m *Maze) DisplayViewport(playerX int, playerY int, viewW int, viewH int) (string, error) {
+	if viewW < 1 || viewH < 1 {
+		return "", fmt.Errorf("Invalid viewport size: %dx%d", viewW, viewH)
+	}
+	return m.display(m.viewportView(playerX, playerY, viewW, viewH), playerX, playerY), nil
+}
+
+// DisplayFog works like DisplayText, but only the tiles within radius of the
+// player can be seen. Everything else, including the start and end if they're
+// too far away, is drawn as a blank space.
+func (m *Maze) DisplayFog(playerX int, playerY int, radius int) (string, error) {
+	if radius < 1 {
+		return "", fmt.Errorf("Invalid fog radius: %d", radius)
+	}
+	v := m.fullView()
+	v.fog = radius
+	return m.display(v, playerX, playerY), nil
+}
+
+// DisplaySolution works like DisplayColored, but a shortest path from the
+// start to the nearest exit is drawn over the board with *. There's no player.
+func (m *Maze) DisplaySolution() (string, error) {
+	exit, _, err := m.nearestExit(m.Start)
+	if err != nil {
+		return "", err
+	}
+	path, err := m.SolvePath(m.Start, exit)
+	if err != nil {
+		return "", err
+	}
+
+	v := m.fullView()
+	v.colored = true
+	v.path = make(map[Coords]bool, len(path))
+	for _, c := range path {
+		v.path[c] = true
+	}
+	return m.display(v, -1, -1), nil
+}
+
+// view says which part of the board display should draw, and how.
+type view struct {
+	left    int
+	top     int
+	w       int
+	h       int
+	colored bool
+	// if fog is more than 0, only tiles within that distance of the player
+	// are drawn and everything else is left blank
+	fog int
+	// tiles on path are drawn as * instead of what's on them, except for
+	// the start and end. If faintPath is set they're drawn dim and only over
+	// empty tiles, so they don't get in the way.
+	path      map[Coords]bool
+	faintPath bool
+	// empty tiles in trail are drawn as a faint dot
+	trail map[Coords]bool
+	// theme is the colors to use if colored is set. Leaving it empty uses
+	// ClassicTheme.
+	theme *Theme
+	// hidePlayer draws whatever the player is standing on instead of @,
+	// which is how the player blinks
+	hidePlayer bool
+	// ghost is where to draw the ghost of the player's best run, if there
+	// is one
+	ghost *Coords
+}
+
+// fullView is a view of the whole board.
+func (m *Maze) fullView() view {
+	return view{w: m.Width, h: m.Height}
+}
+
+// viewportView is a viewW by viewH window of the board centered on the player.
+func (m *Maze) viewportView(playerX int, playerY int, viewW int, viewH int) view {
+	return view{
+		left: viewportStart(playerX, viewW, m.Width),
+		top:  viewportStart(playerY, viewH, m.Height),
+		w:    viewW,
+		h:    viewH,
+	}
+}
+
+// viewportStart finds where a window of length view should start along one
+// side of the board so that it's centered on player, but doesn't go over
+// either end of a board of length size.
+func viewportStart(player int, view int, size int) int {
+	start := player - view/2
+	if start+view > size {
+		start = size - view
+	}
+	if start < 0 {
+		start = 0
+	}
+	return start
+}
+
+// display draws the part of the board described by v, with the player drawn
+// as @. Anything past the edges of the board is left out.
+func (m *Maze) display(v view, playerX int, playerY int) string {
+	theme := v.theme
+	if theme == nil {
+		classic := ClassicTheme()
+		theme = &classic
+	}
+
 	var sb strings.Builder
-	for i, row := range m.Board {
-		for j, tile := range row {
-			if j == playerX && i == playerY {
+	for i := v.top; i < v.top+v.h && i < len(m.Board); i++ {
+		row := m.Board[i]
+		for j := v.left; j < v.left+v.w && j < len(row); j++ {
+			tile := row[j]
+			color, hasColor := theme.tileColor(tile)
+			dx, dy := j-playerX, i-playerY
+			isPlayer := j == playerX && i == playerY && !v.hidePlayer
+			isGhost := v.ghost != nil && *v.ghost == Coords{X: j, Y: i}
+			if v.fog > 0 && dx*dx+dy*dy > v.fog*v.fog {
+				sb.WriteRune(' ')
+			} else if isPlayer && isGhost && v.colored {
+				sb.WriteString(fmt.Sprintf("[%s::b]%c[-::-]", colorTag(theme.Player), GHOST_OVERLAP_GLYPH))
+			} else if isPlayer && isGhost {
+				sb.WriteRune(GHOST_OVERLAP_GLYPH)
+			} else if isPlayer && v.colored {
+				sb.WriteString(fmt.Sprintf("[%s::b]@[-::-]", colorTag(theme.Player)))
+			} else if isPlayer {
 				sb.WriteRune('@')
+			} else if m.enemyAt(Coords{X: j, Y: i}) {
+				if v.colored {
+					sb.WriteString(fmt.Sprintf("[%s::b]%c[-::-]", colorTag(theme.Enemy), ENEMY_GLYPH))
+				} else {
+					sb.WriteRune(ENEMY_GLYPH)
+				}
+			} else if isGhost && v.colored {
+				sb.WriteString(fmt.Sprintf("[%s::d]%c[-::-]", colorTag(theme.Trail), GHOST_GLYPH))
+			} else if isGhost {
+				sb.WriteRune(GHOST_GLYPH)
+			} else if v.path[Coords{X: j, Y: i}] && tile != TILE_START && tile != TILE_END && (!v.faintPath || tile == TILE_EMPTY) {
+				if v.colored && v.faintPath {
+					sb.WriteString(fmt.Sprintf("[%s::d]*[-::-]", colorTag(theme.Path)))
+				} else if v.colored {
+					sb.WriteString(fmt.Sprintf("[%s]*[-]", colorTag(theme.Path)))
+				} else {
+					sb.WriteRune('*')
+				}
+			} else if v.trail[Coords{X: j, Y: i}] && tile == TILE_EMPTY {
+				if v.colored {
+					sb.WriteString(fmt.Sprintf("[%s]·[-]", colorTag(theme.Trail)))
+				} else {
+					sb.WriteRune('·')
+				}
+			} else if v.colored && hasColor {
+				sb.WriteString(fmt.Sprintf("[%s]%c[-]", color, tile))
 			} else {
 				sb.WriteRune(rune(tile))
 			}
@@ -107,5 +515,5 @@ func (m *Maze) DisplayText(playerX int, playerY int) (string, error) {
 		sb.WriteRune('\n')
 	}
 
-	return sb.String(), nil
+	return sb.String()
 }
@@ -0,0 +1,287 @@
+package maze
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// colorTags matches tview color tags like [red] or [#ff0000::b].
+var colorTags = regexp.MustCompile(`\[[^\[\]]*\]`)
+
+func TestDisplayColored(t *testing.T) {
+	m, err := LoadMazeFromString("#####\n#>.<#\n#####\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := m.DisplayText(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != "#####\n#>@<#\n#####\n" {
+		t.Errorf("DisplayText got:\n%s", plain)
+	}
+	if colorTags.MatchString(plain) {
+		t.Errorf("DisplayText has color tags in it:\n%s", plain)
+	}
+
+	colored, err := m.DisplayColored(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tile := range []string{"#", ">", "<", "@"} {
+		if !regexp.MustCompile(`\[[^\[\]]+\]` + regexp.QuoteMeta(tile) + `\[-`).MatchString(colored) {
+			t.Errorf("%s isn't colored in:\n%s", tile, colored)
+		}
+	}
+	if stripped := colorTags.ReplaceAllString(colored, ""); stripped != plain {
+		t.Errorf("without the color tags DisplayColored got:\n%s\nwant:\n%s", stripped, plain)
+	}
+	if strings.Count(colored, "\n") != 3 {
+		t.Errorf("DisplayColored has the wrong number of rows:\n%s", colored)
+	}
+}
+
+func TestDisplaySolution(t *testing.T) {
+	m, err := LoadMazeFromString("#######\n#>....#\n#.###.#\n#...#<#\n#######\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shown, err := m.DisplaySolution()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#######\n#>****#\n#.###*#\n#...#<#\n#######\n"
+	if got := colorTags.ReplaceAllString(shown, ""); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	m, err = LoadMazeFromString("#####\n#>#<#\n#####\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.DisplaySolution(); err == nil {
+		t.Error("showed a solution for a maze that can't be solved")
+	}
+}
+
+func TestClone(t *testing.T) {
+	m, err := LoadMazeFromString("#######\n#>k0E.#\n#$..0<#\n#######\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := m.Clone()
+	if !c.Equal(m) || c.String() != m.String() {
+		t.Fatalf("clone is different:\n%s\nwant:\n%s", c, m)
+	}
+
+	before := m.String()
+	c.Board[1][2] = TILE_EMPTY
+	c.Exits[0] = Coords{X: 1, Y: 1}
+	c.Enemies[0].Pos = Coords{X: 5, Y: 1}
+	c.Treasure[0] = Coords{X: 2, Y: 2}
+	for from := range c.Portals {
+		delete(c.Portals, from)
+	}
+	if m.String() != before || m.Exits[0] != m.End || len(m.Portals) != 2 || m.Treasure[0] != (Coords{X: 1, Y: 2}) {
+		t.Errorf("changing the clone changed the original:\n%s", m)
+	}
+}
+
+func TestLoadMazeCopies(t *testing.T) {
+	m, err := LoadMazeFromString("#####\n#>k<#\n#####\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Game{}
+	g.LoadMaze(m, "test")
+	g.TryMove(POS_X)
+	if g.Keys != 1 || g.CurrentMap.Board[1][2] == TILE_KEY {
+		t.Fatalf("didn't pick up the key:\n%s", g.CurrentMap)
+	}
+	if m.Board[1][2] != TILE_KEY {
+		t.Errorf("picking up the key took it off the original:\n%s", m)
+	}
+
+	// loading it again, like Retry does, puts the key back
+	g.LoadMaze(m, "test")
+	if g.Keys != 0 || g.CurrentMap.Board[1][2] != TILE_KEY {
+		t.Errorf("retrying didn't start over:\n%s", g.CurrentMap)
+	}
+}
+
+func TestLoadMazeLineEndings(t *testing.T) {
+	want, err := LoadMazeFromString("#####\n#>.<#\n#####\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := map[string]string{
+		"no final newline":             "#####\n#>.<#\n#####",
+		"CRLF":                         "#####\r\n#>.<#\r\n#####\r\n",
+		"CRLF without a final newline": "#####\r\n#>.<#\r\n#####",
+	}
+	for name, text := range texts {
+		m, err := LoadMazeFromString(text)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !m.Equal(want) || m.Width != 5 || m.Height != 3 {
+			t.Errorf("%s: loaded a %dx%d maze:\n%s", name, m.Width, m.Height, m)
+		}
+	}
+}
+
+func TestLoadMazeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		// the error has to mention all of these
+		want []string
+	}{
+		{"empty", "", []string{"empty"}},
+		{"just a newline", "\n", []string{"empty"}},
+		{"only metadata", "#! title: Nothing\n", []string{"empty"}},
+		{"blank first row", "\n#####\n#>.<#\n#####\n", []string{"zero width", "line 1"}},
+		{"two final newlines", "#####\n#>.<#\n#####\n\n", []string{"Line 1 is 5 wide", "line 4 is 0 wide"}},
+		{"short row", "#####\n#>.<\n#####\n", []string{"Line 1 is 5 wide", "line 2 is 4 wide"}},
+		{"short row after metadata", "#! title: Short\n#####\n#>.<\n#####\n", []string{"Line 2 is 5 wide", "line 3 is 4 wide"}},
+		{"CRLF short row", "#####\r\n#>.<\r\n#####\r\n", []string{"line 2 is 4 wide"}},
+	}
+	for _, test := range tests {
+		_, err := LoadMazeFromString(test.text)
+		if err == nil {
+			t.Errorf("%s: loaded without an error", test.name)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: error %q doesn't say %q", test.name, err, want)
+			}
+		}
+	}
+}
+
+func TestLoadMazeTabs(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		line string
+	}{
+		{"tab for an empty tile", "#####\n#>\t<#\n#####\n", "Line 2 "},
+		{"CRLF with a tab", "#####\r\n#>.<#\r\n#\t###\r\n", "Line 3 "},
+		{"tab after metadata", "#! title: Tabs\n#####\n#>.<#\t\n#####\n", "Line 3 "},
+	}
+	for _, test := range tests {
+		_, err := LoadMazeFromString(test.text)
+		if err == nil {
+			t.Errorf("%s: loaded without an error", test.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), "tab") || !strings.HasPrefix(err.Error(), test.line) {
+			t.Errorf("%s: got %q, want an error about a tab on %s", test.name, err, strings.ToLower(strings.TrimSpace(test.line)))
+		}
+	}
+
+	// a CRLF file leaves no \r on the board
+	m, err := LoadMazeFromString("#####\r\n#>.<#\r\n#####\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range m.Board {
+		for j, tile := range row {
+			if rune(tile) == '\r' {
+				t.Fatalf("a \\r made it onto the board at %d, %d", j, i)
+			}
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	load := func(text string) *Maze {
+		t.Helper()
+		m, err := LoadMazeFromString(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	m := load("#####\n#>.<#\n#####\n")
+	tests := []struct {
+		name  string
+		other *Maze
+		want  bool
+	}{
+		{"itself", m, true},
+		{"same text", load("#####\n#>.<#\n#####\n"), true},
+		{"space for empty", load("#####\n#> <#\n#####\n"), true},
+		{"different tile", load("#####\n#>#<#\n#####\n"), false},
+		{"swapped ends", load("#####\n#<.>#\n#####\n"), false},
+		{"wider", load("######\n#>..<#\n######\n"), false},
+		{"taller", load("#####\n#>.<#\n#...#\n#####\n"), false},
+		{"nil", nil, false},
+	}
+	for _, test := range tests {
+		if got := m.Equal(test.other); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+		if test.other != nil {
+			if got := test.other.Equal(m); got != test.want {
+				t.Errorf("%s the other way round: got %v, want %v", test.name, got, test.want)
+			}
+		}
+	}
+
+	// the seed and PathLen don't count
+	generated, err := GenerateMaze(5, 5, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded := load(generated.String()); !loaded.Equal(generated) {
+		t.Error("a generated maze isn't equal to itself loaded from a file")
+	}
+	var none *Maze
+	if !none.Equal(nil) {
+		t.Error("two nil mazes aren't equal")
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	texts := []string{
+		"#####\n#>.<#\n#####\n",
+		"#! title: Everything\n#! author: Someone\n#########\n#>kD.mb$#\n#.^v{}i.#\n#1.E.N.1#\n#......<#\n#########\n",
+		"><\n",
+	}
+	for _, text := range texts {
+		m, err := LoadMazeFromString(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.String(); got != text {
+			t.Errorf("got:\n%s\nwant:\n%s", got, text)
+		}
+	}
+
+	// spaces are written back as .
+	m, err := LoadMazeFromString("#####\n#> <#\n#####\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.String(); got != "#####\n#>.<#\n#####\n" {
+		t.Errorf("got:\n%s", got)
+	}
+
+	for _, algorithm := range ALL_ALGORITHMS {
+		m, err := Generate(GenerateOptions{Width: 10, Height: 7, Seed: 3, Algorithm: algorithm})
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadMazeFromString(m.String())
+		if err != nil {
+			t.Fatalf("%v: %v", algorithm, err)
+		}
+		if !loaded.Equal(m) {
+			t.Errorf("%v: got:\n%s\nwant:\n%s", algorithm, loaded, m)
+		}
+	}
+}
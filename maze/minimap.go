@@ -0,0 +1,57 @@
+package maze
+
+import "strings"
+
+// MINIMAP_WIDTH and MINIMAP_HEIGHT are the most characters the minimap takes
+// up during play. The scale is picked so the whole board fits in them.
+const MINIMAP_WIDTH = 24
+const MINIMAP_HEIGHT = 12
+
+// DisplayMinimap draws a shrunk down overview of the board, where each
+// character stands for a scale by scale block of tiles. A block that's mostly
+// wall is drawn as #, the block with the player in it as @, and blocks with an
+// exit in them as <. Everything else is blank.
+func (m *Maze) DisplayMinimap(playerX int, playerY int, scale int) string {
+	scale = max(scale, 1)
+	var sb strings.Builder
+	for top := 0; top < m.Height; top += scale {
+		for left := 0; left < m.Width; left += scale {
+			sb.WriteRune(m.minimapBlock(left, top, scale, playerX, playerY))
+		}
+		sb.WriteRune('\n')
+	}
+	return sb.String()
+}
+
+// minimapBlock is the character for the block of the minimap with its top left
+// corner at left, top.
+func (m *Maze) minimapBlock(left int, top int, scale int, playerX int, playerY int) rune {
+	walls, tiles := 0, 0
+	exit := false
+	for i := top; i < top+scale && i < len(m.Board); i++ {
+		for j := left; j < left+scale && j < len(m.Board[i]); j++ {
+			if i == playerY && j == playerX {
+				return '@'
+			}
+			exit = exit || m.isExit(Coords{X: j, Y: i})
+			if m.Board[i][j] == TILE_WALL {
+				walls++
+			}
+			tiles++
+		}
+	}
+	if exit {
+		return rune(TILE_END)
+	} else if 2*walls > tiles {
+		return rune(TILE_WALL)
+	}
+	return ' '
+}
+
+// minimapScale is the smallest scale that fits the whole board into the
+// minimap.
+func (m *Maze) minimapScale() int {
+	scaleX := (m.Width + MINIMAP_WIDTH - 1) / MINIMAP_WIDTH
+	scaleY := (m.Height + MINIMAP_HEIGHT - 1) / MINIMAP_HEIGHT
+	return max(scaleX, scaleY, 1)
+}
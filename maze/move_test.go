@@ -0,0 +1,92 @@
+package maze
+
+import "testing"
+
+// loadGame loads a maze from text into a new Game, failing the test if it
+// doesn't load.
+func loadGame(t *testing.T, text string) *Game {
+	t.Helper()
+	m, err := LoadMazeFromString(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Game{}
+	g.LoadMaze(m, "test")
+	return g
+}
+
+func TestMoveOntoBoardEdge(t *testing.T) {
+	// the exit is on the right edge with no wall around it, and the
+	// start is on the left edge
+	g := loadGame(t, "#####\n>..k<\n#####\n")
+
+	if moved, _ := g.TryMove(NEG_X); moved {
+		t.Fatal("moved off the left edge of the board")
+	}
+	for i := 0; i < 3; i++ {
+		if moved, won := g.TryMove(POS_X); !moved || won {
+			t.Fatalf("step %d: moved %v, won %v", i, moved, won)
+		}
+	}
+	if moved, won := g.TryMove(POS_X); !moved || !won {
+		t.Fatalf("stepping onto the exit on the edge: moved %v, won %v", moved, won)
+	}
+	if g.PlayerX != 4 || g.PlayerY != 1 {
+		t.Fatalf("player is at %d, %d, want 4, 1", g.PlayerX, g.PlayerY)
+	}
+	if moved, _ := g.TryMove(POS_X); moved {
+		t.Error("moved off the right edge of the board")
+	}
+}
+
+func TestMoveOffEveryEdge(t *testing.T) {
+	// every tile is open, so the only thing stopping the player is the
+	// edge of the board
+	g := loadGame(t, ">.\n.<\n")
+	g.AllowDiagonal = true
+
+	for _, dir := range []Direction{NEG_X, NEG_Y} {
+		if moved, _ := g.TryMove(dir); moved {
+			t.Errorf("moved off the board going %d from the top left", dir)
+		}
+	}
+	if moved, _ := g.tryMove(-1, -1, false); moved {
+		t.Error("moved diagonally off the board")
+	}
+	if moved, _ := g.TryMove(POS_X); !moved {
+		t.Fatal("couldn't move along the top edge")
+	}
+	for _, dir := range []Direction{POS_X, NEG_Y} {
+		if moved, _ := g.TryMove(dir); moved {
+			t.Errorf("moved off the board going %d from the top right", dir)
+		}
+	}
+	if g.CurrentCollisions != 5 {
+		t.Errorf("got %d collisions, want 5", g.CurrentCollisions)
+	}
+}
+
+func TestMoveOnEmptyBoard(t *testing.T) {
+	if _, err := LoadMazeFromString(""); err == nil {
+		t.Error("loaded a maze with no rows")
+	}
+
+	// the loader won't make one, but a Maze built by hand can still have
+	// no rows, or rows with nothing in them
+	for _, m := range []*Maze{{}, {Board: [][]Tile{{}, {}}, Height: 2}} {
+		g := &Game{}
+		g.LoadMaze(m, "empty")
+		for _, dir := range []Direction{POS_Y, POS_X, NEG_Y, NEG_X} {
+			if moved, won := g.TryMove(dir); moved || won {
+				t.Errorf("moving %d on an empty board: moved %v, won %v", dir, moved, won)
+			}
+		}
+		if moved, _ := g.tryMove(1, 1, true); moved {
+			t.Error("ran on an empty board")
+		}
+		g.hint()
+		if s := g.AutoSolve(m); s.Won {
+			t.Error("won an empty board")
+		}
+	}
+}
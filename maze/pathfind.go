@@ -6,9 +6,13 @@ import (
 	"math"
 )
 
+// item is a point in the priority queue. weight is the distance travelled to
+// get there, and fScore is what the queue is actually sorted by: for Dijkstra
+// it's the same as weight, for A* it also includes the heuristic.
 type item struct {
 	pos    Coords
 	weight int
+	fScore int
 	index  int
 }
 
@@ -22,7 +26,7 @@ func (q pointQueue) Len() int {
 }
 
 func (q pointQueue) Less(i, j int) bool {
-	return q[i].weight < q[j].weight
+	return q[i].fScore < q[j].fScore
 }
 
 func (q pointQueue) Swap(i, j int) {
@@ -48,11 +52,34 @@ func (q *pointQueue) Pop() any {
 	return item
 }
 
+// MUD_COST is how many steps it takes to walk onto a mud tile.
+const MUD_COST int = 3
+
+// tileCost is how many steps it takes to walk onto a tile.
+func tileCost(t Tile) int {
+	if t == TILE_MUD {
+		return MUD_COST
+	}
+	return 1
+}
+
+// isPassage reports whether a tunnel between two cells of a generated maze
+// is open.
+func isPassage(t Tile) bool {
+	return t == TILE_EMPTY || t == TILE_MUD
+}
+
 // CreateSpt creates a shortest path tree using Dijkstra's algorithm given a
-// certain point on a board.
+// certain point on a board. Distances are counted in cells, so they're half
+// what DistancesFrom gives for the same tile, and they take the cost of
+// crossing mud into account the same way.
 // This is intended to be used with generated mazes, so the coordinates should
 // be (2m+1, 2n+1) where m and n are integers (i.e. one of the "cells" used in
-// generation and not the tunnels between them).
+// generation and not the tunnels between them). For any other board use
+// DistancesFrom.
+// Every edge is treated as going both ways, so one-way tiles aren't handled.
+// The generators never make them, but using this on a hand-made map with
+// them would need the edges to be directed like they are in openNeighbors.
 func (m *Maze) CreateSpt(src Coords) ([][]int, error) {
 	if len(m.Board)%2 != 1 || len(m.Board[0])%2 != 1 {
 		return nil, errors.New("Invalid board dimensions. Are you sure this is a generated maze?")
@@ -91,33 +118,389 @@ func (m *Maze) CreateSpt(src Coords) ([][]int, error) {
 
 	for pq.Len() != 0 {
 		// get the lowest "weight" square in the queue
-		current := pq.Pop().(*item)
+		current := heap.Pop(&pq).(*item)
 
 		// Check all accessible adjacent squares
 		adj := make([]Coords, 0, 4)
 		// we *shouldn't* need to check if the coordinate is zero or
 		// maximum, because then the board should have a wall there
-		if m.Board[current.pos.Y*2][current.pos.X*2+1] == TILE_EMPTY {
+		if isPassage(m.Board[current.pos.Y*2][current.pos.X*2+1]) {
 			adj = append(adj, Coords{X: current.pos.X, Y: current.pos.Y - 1})
 		}
-		if m.Board[current.pos.Y*2+2][current.pos.X*2+1] == TILE_EMPTY {
+		if isPassage(m.Board[current.pos.Y*2+2][current.pos.X*2+1]) {
 			adj = append(adj, Coords{X: current.pos.X, Y: current.pos.Y + 1})
 		}
-		if m.Board[current.pos.Y*2+1][current.pos.X*2+2] == TILE_EMPTY {
+		if isPassage(m.Board[current.pos.Y*2+1][current.pos.X*2+2]) {
 			adj = append(adj, Coords{X: current.pos.X + 1, Y: current.pos.Y})
 		}
-		if m.Board[current.pos.Y*2+1][current.pos.X*2] == TILE_EMPTY {
+		if isPassage(m.Board[current.pos.Y*2+1][current.pos.X*2]) {
 			adj = append(adj, Coords{X: current.pos.X - 1, Y: current.pos.Y})
 		}
 
 		for _, point := range adj {
-			newDist := distances[current.pos.Y][current.pos.X] + 1
+			// Moving to the next cell means walking through the tunnel
+			// and then onto the cell. Distances are in cells, which are
+			// two tiles apart, so that's half of what the two tiles
+			// cost. For a maze without mud this is always 1.
+			tunnel := m.Board[current.pos.Y+point.Y+1][current.pos.X+point.X+1]
+			cell := m.Board[point.Y*2+1][point.X*2+1]
+			cost := (tileCost(tunnel) + tileCost(cell)) / 2
+
+			newDist := distances[current.pos.Y][current.pos.X] + cost
 			if newDist < distances[point.Y][point.X] {
 				distances[point.Y][point.X] = newDist
-				heap.Push(&pq, &item{pos: point, weight: newDist})
+				heap.Push(&pq, &item{pos: point, weight: newDist, fScore: newDist})
 			}
 		}
 	}
 
 	return distances, nil
 }
+
+// ShortestDistance finds the length of the shortest path from src to dest
+// using A* with a Manhattan distance heuristic. Like CreateSpt, mud counts as
+// MUD_COST steps. When you only need the
+// distance to one point this is much faster than CreateSpt because it stops
+// as soon as it reaches dest, and it works on any board.
+func (m *Maze) ShortestDistance(src Coords, dest Coords) (int, error) {
+	if !m.passable(src) {
+		return -1, errors.New("Source point is not an open tile")
+	}
+	if !m.passable(dest) {
+		return -1, errors.New("Destination point is not an open tile")
+	}
+
+	distances := make([][]int, len(m.Board))
+	for i, row := range m.Board {
+		distances[i] = make([]int, len(row))
+		for j, _ := range distances[i] {
+			distances[i][j] = math.MaxInt
+		}
+	}
+	distances[src.Y][src.X] = 0
+
+	// Portals and ice can both take you more than one tile in a single
+	// step, so the distance on the board could overestimate. Without a
+	// heuristic this is just Dijkstra's.
+	heuristic := manhattan
+	if m.hasShortcuts() {
+		heuristic = func(Coords, Coords) int { return 0 }
+	}
+
+	var pq pointQueue
+	heap.Init(&pq)
+	heap.Push(&pq, &item{pos: src, weight: 0, fScore: heuristic(src, dest)})
+
+	for pq.Len() != 0 {
+		current := heap.Pop(&pq).(*item)
+		// The heuristic never overestimates, so the first time we pop
+		// the destination we've found the shortest way there.
+		if current.pos == dest {
+			return current.weight, nil
+		}
+		// skip stale entries that were already beaten by a shorter path
+		if current.weight > distances[current.pos.Y][current.pos.X] {
+			continue
+		}
+
+		for _, point := range m.openNeighbors(current.pos) {
+			newDist := current.weight + tileCost(m.Board[point.Y][point.X])
+			if newDist < distances[point.Y][point.X] {
+				distances[point.Y][point.X] = newDist
+				heap.Push(&pq, &item{
+					pos:    point,
+					weight: newDist,
+					fScore: newDist + heuristic(point, dest),
+				})
+			}
+		}
+	}
+
+	return -1, errors.New("No path exists between the given points")
+}
+
+// hasShortcuts reports whether the maze has any portals or ice.
+func (m *Maze) hasShortcuts() bool {
+	if len(m.Portals) > 0 {
+		return true
+	}
+	for _, row := range m.Board {
+		for _, tile := range row {
+			if tile == TILE_ICE {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func manhattan(a Coords, b Coords) int {
+	dx := a.X - b.X
+	if dx < 0 {
+		dx = -dx
+	}
+	dy := a.Y - b.Y
+	if dy < 0 {
+		dy = -dy
+	}
+	return dx + dy
+}
+
+// SolvePath finds a shortest path from src to dest using Dijkstra's
+// algorithm, with mud costing MUD_COST steps the same as it does everywhere
+// else. Unlike CreateSpt this works on any board, hand-made or generated.
+// The returned path starts with src and ends with dest.
+func (m *Maze) SolvePath(src Coords, dest Coords) ([]Coords, error) {
+	if !m.passable(src) {
+		return nil, errors.New("Source point is not an open tile")
+	}
+	if !m.passable(dest) {
+		return nil, errors.New("Destination point is not an open tile")
+	}
+
+	// prev remembers where we came from to reach each tile, and distances
+	// doubles as the set of tiles we've already seen
+	prev := map[Coords]Coords{src: src}
+	distances := map[Coords]int{src: 0}
+	var pq pointQueue
+	heap.Init(&pq)
+	heap.Push(&pq, &item{pos: src})
+	for pq.Len() != 0 {
+		current := heap.Pop(&pq).(*item)
+		if current.pos == dest {
+			break
+		}
+		// skip stale entries that were already beaten by a shorter path
+		if current.weight > distances[current.pos] {
+			continue
+		}
+
+		for _, next := range m.openNeighbors(current.pos) {
+			newDist := current.weight + tileCost(m.Board[next.Y][next.X])
+			if old, seen := distances[next]; !seen || newDist < old {
+				distances[next] = newDist
+				prev[next] = current.pos
+				heap.Push(&pq, &item{pos: next, weight: newDist, fScore: newDist})
+			}
+		}
+	}
+
+	if _, ok := prev[dest]; !ok {
+		return nil, errors.New("No path exists between the given points")
+	}
+
+	// walk backwards from the destination and then flip the path around
+	path := []Coords{dest}
+	for path[len(path)-1] != src {
+		path = append(path, prev[path[len(path)-1]])
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path, nil
+}
+
+// ComputePathLen sets PathLen to the length of the shortest path from Start
+// to the nearest exit. Loaded mazes start out with a PathLen of -1, so this
+// has to be run before they can be scored.
+func (m *Maze) ComputePathLen() error {
+	_, dist, err := m.nearestExit(m.Start)
+	if err != nil {
+		return err
+	}
+	m.PathLen = dist
+	return nil
+}
+
+// exits returns every exit of the maze. Mazes that were put together by hand
+// might only have End set.
+func (m *Maze) exits() []Coords {
+	if len(m.Exits) == 0 {
+		return []Coords{m.End}
+	}
+	return m.Exits
+}
+
+// isExit reports whether c is one of the exits.
+func (m *Maze) isExit(c Coords) bool {
+	for _, exit := range m.exits() {
+		if exit == c {
+			return true
+		}
+	}
+	return false
+}
+
+// nearestExit finds the exit that takes the fewest steps to get to from src,
+// and how many steps that is.
+func (m *Maze) nearestExit(src Coords) (Coords, int, error) {
+	distances, err := m.DistancesFrom(src)
+	if err != nil {
+		return Coords{}, -1, err
+	}
+
+	var nearest Coords
+	best := -1
+	for _, exit := range m.exits() {
+		if !m.passable(exit) {
+			return Coords{}, -1, errors.New("Destination point is not an open tile")
+		}
+		if dist := distances[exit.Y][exit.X]; dist >= 0 && (best < 0 || dist < best) {
+			nearest, best = exit, dist
+		}
+	}
+	if best < 0 {
+		return Coords{}, -1, errors.New("No path exists between the given points")
+	}
+	return nearest, best, nil
+}
+
+// DistancesFrom finds how many steps it takes to get from src to every tile
+// on the board using Dijkstra's algorithm. Unlike CreateSpt it works on any
+// board, since every tile that isn't a wall is its own node. Walls and tiles
+// that can't be reached from src get a distance of -1.
+func (m *Maze) DistancesFrom(src Coords) ([][]int, error) {
+	if !m.passable(src) {
+		return nil, errors.New("Source point is not an open tile")
+	}
+
+	distances := make([][]int, len(m.Board))
+	for i, row := range m.Board {
+		distances[i] = make([]int, len(row))
+		for j := range distances[i] {
+			distances[i][j] = -1
+		}
+	}
+	distances[src.Y][src.X] = 0
+
+	var pq pointQueue
+	heap.Init(&pq)
+	heap.Push(&pq, &item{pos: src})
+
+	for pq.Len() != 0 {
+		current := heap.Pop(&pq).(*item)
+		// skip stale entries that were already beaten by a shorter path
+		if current.weight > distances[current.pos.Y][current.pos.X] {
+			continue
+		}
+
+		for _, point := range m.openNeighbors(current.pos) {
+			newDist := current.weight + tileCost(m.Board[point.Y][point.X])
+			if old := distances[point.Y][point.X]; old < 0 || newDist < old {
+				distances[point.Y][point.X] = newDist
+				heap.Push(&pq, &item{pos: point, weight: newDist, fScore: newDist})
+			}
+		}
+	}
+
+	return distances, nil
+}
+
+// IsSolvable does a flood fill from the start of the maze and reports whether
+// it reaches any of the exits. Doors count as open here, so it doesn't check that
+// there are enough keys to get through them.
+func (m *Maze) IsSolvable() (bool, error) {
+	if !m.passable(m.Start) || m.Board[m.Start.Y][m.Start.X] != TILE_START {
+		return false, errors.New("Maze has no start point")
+	}
+	if !m.passable(m.End) || m.Board[m.End.Y][m.End.X] != TILE_END {
+		return false, errors.New("Maze has no end point")
+	}
+
+	seen := map[Coords]bool{m.Start: true}
+	stack := []Coords{m.Start}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if m.isExit(current) {
+			return true, nil
+		}
+
+		for _, next := range m.openNeighbors(current) {
+			if !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// passable reports whether c is on the board and not a wall.
+func (m *Maze) passable(c Coords) bool {
+	t, ok := m.tileAt(c.X, c.Y)
+	return ok && t != TILE_WALL
+}
+
+// tileAt returns the tile at x, y, or false if that's off the board. It goes
+// by the board itself rather than Width and Height, so it's safe even on a
+// maze that was put together by hand.
+func (m *Maze) tileAt(x int, y int) (Tile, bool) {
+	if y < 0 || y >= len(m.Board) || x < 0 || x >= len(m.Board[y]) {
+		return 0, false
+	}
+	return m.Board[y][x], true
+}
+
+// openSides counts how many of the tiles next to c aren't walls. It only
+// looks at the board, so portals and one-way tiles don't change it.
+func (m *Maze) openSides(c Coords) int {
+	sides := 0
+	for _, n := range []Coords{{X: c.X, Y: c.Y - 1}, {X: c.X, Y: c.Y + 1}, {X: c.X - 1, Y: c.Y}, {X: c.X + 1, Y: c.Y}} {
+		if m.passable(n) {
+			sides++
+		}
+	}
+	return sides
+}
+
+// DeadEnds finds every tile that isn't a wall and only has one open side. It
+// works on any board, unlike the generators' deadEndCells which only works in
+// cell coordinates.
+func (m *Maze) DeadEnds() []Coords {
+	var ends []Coords
+	for y, row := range m.Board {
+		for x := range row {
+			c := Coords{X: x, Y: y}
+			if m.passable(c) && m.openSides(c) == 1 {
+				ends = append(ends, c)
+			}
+		}
+	}
+	return ends
+}
+
+// openNeighbors returns the tiles that can be reached from c in one move.
+func (m *Maze) openNeighbors(c Coords) []Coords {
+	neighbors := make([]Coords, 0, 4)
+	for _, d := range []Coords{{X: 0, Y: -1}, {X: 0, Y: 1}, {X: -1, Y: 0}, {X: 1, Y: 0}} {
+		if n, ok := m.stepFrom(c, d.X, d.Y); ok {
+			neighbors = append(neighbors, n)
+		}
+	}
+	return neighbors
+}
+
+// stepFrom works out where moving by dx and dy from c ends up, if it's
+// allowed. Since walking onto a portal takes you straight to its pair, the
+// pair is returned instead of the portal itself, and since you slide across
+// ice the tile where the slide stops is returned instead of the ice. If c is
+// a one-way tile, you can only move the way it points.
+func (m *Maze) stepFrom(c Coords, dx int, dy int) (Coords, bool) {
+	if wantX, wantY, ok := m.Board[c.Y][c.X].OneWay(); ok && (dx != wantX || dy != wantY) {
+		return c, false
+	}
+	n := Coords{X: c.X + dx, Y: c.Y + dy}
+	if !m.passable(n) {
+		return c, false
+	}
+	for m.Board[n.Y][n.X] == TILE_ICE && m.passable(Coords{X: n.X + dx, Y: n.Y + dy}) {
+		n = Coords{X: n.X + dx, Y: n.Y + dy}
+	}
+	if pair, ok := m.Portals[n]; ok {
+		n = pair
+	}
+	return n, true
+}
@@ -0,0 +1,346 @@
+package maze
+
+import "testing"
+
+// checkPath fails the test unless path goes from src to dest one open tile
+// at a time.
+func checkPath(t *testing.T, m *Maze, path []Coords, src Coords, dest Coords) {
+	t.Helper()
+	if len(path) == 0 || path[0] != src || path[len(path)-1] != dest {
+		t.Fatalf("path %v doesn't go from %v to %v", path, src, dest)
+	}
+	for i, c := range path {
+		if !m.passable(c) {
+			t.Errorf("step %d at %v isn't an open tile", i, c)
+		}
+		if i > 0 && manhattan(c, path[i-1]) != 1 {
+			t.Errorf("step %d jumps from %v to %v", i, path[i-1], c)
+		}
+	}
+}
+
+func TestSolvePath(t *testing.T) {
+	m, err := LoadMazeFromString("#######\n#>....#\n#####.#\n#<....#\n#######\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := m.SolvePath(m.Start, m.End)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPath(t, m, path, m.Start, m.End)
+	if len(path) != 11 {
+		t.Errorf("path has %d tiles, want 11", len(path))
+	}
+
+	for seed := int64(1); seed <= 10; seed++ {
+		m, err := GenerateMaze(15, 10, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path, err := m.SolvePath(m.Start, m.End)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		checkPath(t, m, path, m.Start, m.End)
+		if len(path)-1 != m.PathLen {
+			t.Errorf("seed %d: path takes %d steps, want %d", seed, len(path)-1, m.PathLen)
+		}
+	}
+}
+
+func TestSolvePathErrors(t *testing.T) {
+	m, err := LoadMazeFromString("#####\n#>#<#\n#####\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.SolvePath(m.Start, m.End); err == nil {
+		t.Error("found a path through a wall")
+	}
+	if _, err := m.SolvePath(Coords{X: 0, Y: 0}, m.End); err == nil {
+		t.Error("found a path starting in a wall")
+	}
+	if _, err := m.SolvePath(m.Start, Coords{X: 2, Y: 1}); err == nil {
+		t.Error("found a path ending in a wall")
+	}
+}
+
+// benchmarkMaze is a generated maze with a 101x101 board.
+func benchmarkMaze(b *testing.B) *Maze {
+	b.Helper()
+	m, err := GenerateMaze(50, 50, 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return m
+}
+
+func BenchmarkShortestDistance(b *testing.B) {
+	m := benchmarkMaze(b)
+	path, err := m.SolvePath(m.Start, m.End)
+	if err != nil {
+		b.Fatal(err)
+	}
+	// the exit is about as far from the start as it gets, so A* can only
+	// stop early when it's after somewhere closer
+	targets := []struct {
+		name string
+		dest Coords
+	}{
+		{"exit", m.End},
+		{"nearby", path[20]},
+	}
+	for _, target := range targets {
+		b.Run(target.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := m.ShortestDistance(m.Start, target.dest); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCreateSpt(b *testing.B) {
+	m := benchmarkMaze(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.CreateSpt(m.Start); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestShortestDistanceMatchesCreateSpt(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		m, err := GenerateMaze(20, 20, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spt, err := m.CreateSpt(m.Start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dist, err := m.ShortestDistance(m.Start, m.End)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// CreateSpt counts in cells, which are two tiles apart
+		want := 2 * spt[(m.End.Y-1)/2][(m.End.X-1)/2]
+		if dist != want {
+			t.Errorf("seed %d: ShortestDistance got %d, CreateSpt got %d", seed, dist, want)
+		}
+	}
+}
+
+func TestCreateSptMatchesDistancesFrom(t *testing.T) {
+	// mud in a tunnel, mud on a cell, and mud on both
+	m, err := LoadMazeFromString("#######\n#>m.#.#\n#.#.#.#\n#m..m<#\n#######\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spt, err := m.CreateSpt(m.Start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	distances, err := m.DistancesFrom(m.Start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y, row := range spt {
+		for x, dist := range row {
+			if want := distances[2*y+1][2*x+1]; 2*dist != want {
+				t.Errorf("cell %d, %d: CreateSpt got %d cells, DistancesFrom got %d tiles", x, y, dist, want)
+			}
+		}
+	}
+}
+
+func TestComputePathLen(t *testing.T) {
+	tests := []struct {
+		name string
+		maze string
+		want int
+	}{
+		{"straight", "#####\n#>.<#\n#####\n", 2},
+		{"next to each other", "><\n", 1},
+		{"winding", "#######\n#>....#\n#####.#\n#<....#\n#######\n", 10},
+		{"not 2n+1", "######\n#>...#\n#.##.#\n#...<#\n######\n", 5},
+		{"mud", "#####\n#>m<#\n#####\n", MUD_COST + 1},
+		{"around the mud", "#####\n#>m<#\n#...#\n#####\n", 4},
+	}
+	for _, test := range tests {
+		m, err := LoadMazeFromString(test.maze)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if m.PathLen != -1 {
+			t.Errorf("%s: loaded with a PathLen of %d, want -1", test.name, m.PathLen)
+		}
+		if err := m.ComputePathLen(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if m.PathLen != test.want {
+			t.Errorf("%s: PathLen is %d, want %d", test.name, m.PathLen, test.want)
+		}
+	}
+
+	m, err := LoadMazeFromString("#####\n#>#<#\n#####\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ComputePathLen(); err == nil {
+		t.Errorf("got a PathLen of %d with no way to the end", m.PathLen)
+	}
+}
+
+func TestDistancesFrom(t *testing.T) {
+	// six wide and five high, so CreateSpt won't take it
+	m, err := LoadMazeFromString("######\n#>..##\n#.#..#\n#m..<#\n######\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.CreateSpt(m.Start); err == nil {
+		t.Fatal("CreateSpt took a board that isn't 2n+1")
+	}
+
+	distances, err := m.DistancesFrom(m.Start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]int{
+		{-1, -1, -1, -1, -1, -1},
+		{-1, 0, 1, 2, -1, -1},
+		{-1, 1, -1, 3, 4, -1},
+		{-1, 1 + MUD_COST, 5, 4, 5, -1},
+		{-1, -1, -1, -1, -1, -1},
+	}
+	for y, row := range want {
+		for x, dist := range row {
+			if distances[y][x] != dist {
+				t.Errorf("distance to %d, %d is %d, want %d", x, y, distances[y][x], dist)
+			}
+		}
+	}
+
+	if _, err := m.DistancesFrom(Coords{X: 0, Y: 0}); err == nil {
+		t.Error("got distances from a wall")
+	}
+}
+
+func TestMultipleExits(t *testing.T) {
+	// one exit is four steps away on the right and the other is six
+	// steps away on the left
+	text := "#############\n#<.....>...<#\n#############\n"
+	m, err := LoadMazeFromString(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Exits) != 2 {
+		t.Fatalf("got %d exits, want 2", len(m.Exits))
+	}
+	if err := m.ComputePathLen(); err != nil {
+		t.Fatal(err)
+	}
+	if m.PathLen != 4 {
+		t.Errorf("PathLen is %d, want 4 to the nearest exit", m.PathLen)
+	}
+
+	// either exit wins
+	for _, dir := range []Direction{NEG_X, POS_X} {
+		g := loadGame(t, text)
+		won := false
+		for i := 0; i < 10 && !won; i++ {
+			var moved bool
+			if moved, won = g.TryMove(dir); !moved {
+				t.Fatalf("got stuck going %d", dir)
+			}
+		}
+		if !won {
+			t.Errorf("didn't win going %d", dir)
+		}
+	}
+}
+
+func TestDeadEnds(t *testing.T) {
+	m, err := LoadMazeFromString("#######\n#>..#.#\n#.#...#\n#.#.#<#\n#######\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Coords{{X: 5, Y: 1}, {X: 1, Y: 3}, {X: 3, Y: 3}, {X: 5, Y: 3}}
+	got := m.DeadEnds()
+	if len(got) != len(want) {
+		t.Fatalf("got dead ends %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got dead ends %v, want %v", got, want)
+		}
+	}
+
+	// a board that isn't 2n+1 and has no walls around it
+	m, err = LoadMazeFromString("><\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.DeadEnds(); len(got) != 2 {
+		t.Errorf("got dead ends %v, want both tiles", got)
+	}
+
+	// on a generated maze the tunnels between cells always have two open
+	// sides, so it finds the same dead ends the generators do
+	for seed := int64(1); seed <= 10; seed++ {
+		m, err := GenerateMaze(12, 9, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(m.DeadEnds()), len(deadEndCells(m.Board, 12, 9)); got != want {
+			t.Errorf("seed %d: got %d dead ends, want %d", seed, got, want)
+		}
+	}
+}
+
+// pathCost adds up what it costs to walk path, not counting the tile it
+// starts on.
+func pathCost(m *Maze, path []Coords) int {
+	cost := 0
+	for _, c := range path[1:] {
+		cost += tileCost(m.Board[c.Y][c.X])
+	}
+	return cost
+}
+
+func TestSolvePathAvoidsMud(t *testing.T) {
+	mazes := []string{
+		// going round is 8 steps, going through the mud is 10
+		"#######\n#>mmm<#\n#.###.#\n#.....#\n#######\n",
+		// one tile of mud is cheaper than going round
+		"#######\n#>.m.<#\n#.###.#\n#.....#\n#######\n",
+		"#####\n#>m<#\n#####\n",
+	}
+	for _, text := range mazes {
+		m, err := LoadMazeFromString(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.ComputePathLen(); err != nil {
+			t.Fatal(err)
+		}
+		path, err := m.SolvePath(m.Start, m.End)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkPath(t, m, path, m.Start, m.End)
+		if cost := pathCost(m, path); cost != m.PathLen {
+			t.Errorf("path %v costs %d, but PathLen is %d:\n%s", path, cost, m.PathLen, m)
+		}
+	}
+
+	g := loadGame(t, mazes[0])
+	if got := g.hint(); got != "Hint: go down" {
+		t.Errorf("got %q, want the hint to go round the mud", got)
+	}
+	if s := g.AutoSolve(g.loadedMap); !s.Won || g.CurrentSteps != 8 {
+		t.Errorf("auto-solve took %d steps, want 8", g.CurrentSteps)
+	}
+}
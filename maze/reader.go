@@ -0,0 +1,51 @@
+package maze
+
+import "io/fs"
+
+// MazeReader is anything a maze can be loaded from. Adding a new format just
+// means writing a new MazeReader for it.
+type MazeReader interface {
+	Read() (*Maze, error)
+}
+
+// StringReader reads a maze drawn in ASCII, like the files in the data folder.
+type StringReader struct {
+	Source string
+}
+
+func (r StringReader) Read() (*Maze, error) {
+	return LoadMazeFromString(r.Source)
+}
+
+// FileReader reads a maze drawn in ASCII from a file.
+type FileReader struct {
+	Path string
+}
+
+func (r FileReader) Read() (*Maze, error) {
+	return LoadMazeFromFile(r.Path)
+}
+
+// FSReader reads a maze drawn in ASCII from a file in fsys, like the maps
+// built into the binary.
+type FSReader struct {
+	FS   fs.FS
+	Path string
+}
+
+func (r FSReader) Read() (*Maze, error) {
+	content, err := fs.ReadFile(r.FS, r.Path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadMazeFromString(string(content))
+}
+
+// JSONReader reads a maze in the format written by MarshalJSON.
+type JSONReader struct {
+	Data []byte
+}
+
+func (r JSONReader) Read() (*Maze, error) {
+	return LoadMazeFromJSON(r.Data)
+}
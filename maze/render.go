@@ -0,0 +1,61 @@
+package maze
+
+import (
+	"strings"
+)
+
+// RenderOptions picks the characters Render uses to draw a maze. Tiles that
+// don't have an option here (keys, doors, mud and portals) are drawn as the
+// characters they're written as in maze files.
+type RenderOptions struct {
+	Wall   rune
+	Empty  rune
+	Start  rune
+	End    rune
+	Player rune
+	// PlayerX and PlayerY say where to draw the player. Set them to -1 to
+	// leave the player out.
+	PlayerX int
+	PlayerY int
+}
+
+// DefaultRenderOptions returns the options that make Render draw exactly what
+// DisplayText does, with no player on the board.
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{
+		Wall:    rune(TILE_WALL),
+		Empty:   rune(TILE_EMPTY),
+		Start:   rune(TILE_START),
+		End:     rune(TILE_END),
+		Player:  '@',
+		PlayerX: -1,
+		PlayerY: -1,
+	}
+}
+
+// Render draws the board as text using the characters in opts, e.g. to use
+// Unicode block characters for walls instead of '#'.
+func (m *Maze) Render(opts RenderOptions) string {
+	glyphs := map[Tile]rune{
+		TILE_WALL:  opts.Wall,
+		TILE_EMPTY: opts.Empty,
+		TILE_START: opts.Start,
+		TILE_END:   opts.End,
+	}
+
+	var sb strings.Builder
+	for i, row := range m.Board {
+		for j, tile := range row {
+			if j == opts.PlayerX && i == opts.PlayerY {
+				sb.WriteRune(opts.Player)
+			} else if glyph, ok := glyphs[tile]; ok {
+				sb.WriteRune(glyph)
+			} else {
+				sb.WriteRune(rune(tile))
+			}
+		}
+		sb.WriteRune('\n')
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,155 @@
+package maze
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// REPLAY_DELAY is how long a replay waits between moves.
+const REPLAY_DELAY = 150 * time.Millisecond
+
+// Replay is a recording of a run through a maze. Level maps are loaded again
+// by name. Generated mazes are made again from the seed and the size in cells
+// with whichever Algorithm made them, which is GenerateMaze unless Endless
+// was set up to use something else.
+type Replay struct {
+	Map       string    `json:"map"`
+	Seed      int64     `json:"seed,omitempty"`
+	Width     int       `json:"width,omitempty"`
+	Height    int       `json:"height,omitempty"`
+	Algorithm Algorithm `json:"algorithm,omitempty"`
+	// Moves are every move in the order they were made
+	Moves []ReplayMove `json:"moves"`
+}
+
+// ReplayMove is one move in a replay. X and Y are which way the player went,
+// or which way they blew up a wall if Bomb is set.
+type ReplayMove struct {
+	X    int  `json:"x"`
+	Y    int  `json:"y"`
+	Bomb bool `json:"bomb,omitempty"`
+}
+
+// newReplay starts an empty recording for a maze.
+func newReplay(m *Maze, name string) *Replay {
+	r := &Replay{Map: name}
+	if m.Seed != 0 {
+		r.Seed = m.Seed
+		r.Width = (m.Width - 1) / 2
+		r.Height = (m.Height - 1) / 2
+	}
+	return r
+}
+
+// generate makes the generated maze the replay was recorded on again.
+func (r *Replay) generate() (*Maze, error) {
+	return Generate(GenerateOptions{Width: r.Width, Height: r.Height, Seed: r.Seed, Algorithm: r.Algorithm})
+}
+
+// record adds a move to the end of the replay.
+func (r *Replay) record(dx int, dy int) {
+	r.Moves = append(r.Moves, ReplayMove{X: dx, Y: dy})
+}
+
+// recordBomb adds a bomb going off to the end of the replay.
+func (r *Replay) recordBomb(dx int, dy int) {
+	r.Moves = append(r.Moves, ReplayMove{X: dx, Y: dy, Bomb: true})
+}
+
+// undo drops the last move, so a replay only has the moves that counted.
+func (r *Replay) undo() {
+	if len(r.Moves) > 0 {
+		r.Moves = r.Moves[:len(r.Moves)-1]
+	}
+}
+
+// Save writes the replay out as JSON.
+func (r *Replay) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// Load reads a replay written by Save.
+func (r *Replay) Load(rd io.Reader) error {
+	var loaded Replay
+	if err := json.NewDecoder(rd).Decode(&loaded); err != nil {
+		return fmt.Errorf("Couldn't read replay: %w", err)
+	}
+	if loaded.Seed != 0 && (loaded.Width <= 0 || loaded.Height <= 0) {
+		return fmt.Errorf("Replay of a generated maze has a bad size: %dx%d", loaded.Width, loaded.Height)
+	}
+	*r = loaded
+	return nil
+}
+
+// WatchReplay loads the maze a replay was recorded on and plays the moves
+// back one at a time. Pressing any key stops it and goes back to whatever
+// page was showing before.
+func (g *Game) WatchReplay(r *Replay) {
+	if r.Seed != 0 {
+		m, err := r.generate()
+		if err != nil {
+			g.DisplayError(err)
+			return
+		}
+		g.LoadMaze(m, r.Map)
+	} else if !g.LoadFile(r.Map) {
+		return
+	}
+
+	previous, _ := g.Pages.GetFrontPage()
+	view := tview.NewTextView().SetDynamicColors(true)
+	played := 0
+	draw := func() {
+		header := fmt.Sprintf("REPLAY: %s\nMove %d of %d - press any key to stop\n\n", r.Map, played, len(r.Moves))
+		view.SetText(header + g.CurrentMap.display(g.CurrentMap.fullView(), g.PlayerX, g.PlayerY))
+	}
+
+	// stop is only closed on the UI thread, and the moves are applied there
+	// too, so checking it before each move is enough
+	stop := make(chan struct{})
+	stopped := false
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if !stopped {
+			stopped = true
+			close(stop)
+		}
+		g.Pages.RemovePage("replay")
+		g.Pages.SwitchToPage(previous)
+		return nil
+	})
+
+	go func() {
+		defer g.Recover()
+		for _, move := range r.Moves {
+			select {
+			case <-stop:
+				return
+			case <-time.After(REPLAY_DELAY):
+			}
+
+			move := move
+			g.Application.QueueUpdateDraw(func() {
+				if stopped {
+					return
+				}
+				if move.Bomb {
+					g.mu.Lock()
+					g.useBomb(move.X, move.Y)
+					g.mu.Unlock()
+				} else {
+					g.tryMove(move.X, move.Y, false)
+				}
+				played++
+				draw()
+			})
+		}
+	}()
+
+	g.Pages.AddAndSwitchToPage("replay", view, true)
+	draw()
+}
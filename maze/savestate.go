@@ -0,0 +1,182 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// GameState is a snapshot of a stage in the middle of being played, so the
+// player can quit and pick it up again later. The map is found again the same
+// way a Replay finds it, and the moves are played back on it so keys, doors
+// and walls that got blown up end up the way they were.
+type GameState struct {
+	Map    string `json:"map"`
+	Seed   int64  `json:"seed,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	// Algorithm is what generated the maze, so it comes out the same again
+	Algorithm Algorithm `json:"algorithm,omitempty"`
+
+	PlayerX      int `json:"player_x"`
+	PlayerY      int `json:"player_y"`
+	CurrentSteps int `json:"steps"`
+	Keys         int `json:"keys"`
+	Bombs        int `json:"bombs"`
+	Treasure     int `json:"treasure"`
+	Collisions   int `json:"collisions"`
+	Hints        int `json:"hints"`
+	Peeks        int `json:"peeks"`
+	// Elapsed is how long the stage had been going for in timed mode
+	Elapsed      time.Duration `json:"elapsed,omitempty"`
+	Daily        bool          `json:"daily,omitempty"`
+	CampaignMode bool          `json:"campaign,omitempty"`
+	Moves        []ReplayMove  `json:"moves"`
+}
+
+// SaveState writes a snapshot of the stage being played to w. Endless runs
+// can't be saved since the rounds before this one would be lost.
+func (g *Game) SaveState(w io.Writer) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.CurrentMap == nil || g.Recording == nil {
+		return errors.New("There's no game going to save")
+	}
+	if g.Endless {
+		return errors.New("Endless runs can't be saved")
+	}
+
+	state := GameState{
+		Map:          g.Recording.Map,
+		Seed:         g.Recording.Seed,
+		Width:        g.Recording.Width,
+		Height:       g.Recording.Height,
+		Algorithm:    g.Recording.Algorithm,
+		PlayerX:      g.PlayerX,
+		PlayerY:      g.PlayerY,
+		CurrentSteps: g.CurrentSteps,
+		Keys:         g.Keys,
+		Bombs:        g.Bombs,
+		Treasure:     g.Treasure,
+		Collisions:   g.CurrentCollisions,
+		Hints:        g.HintsUsed,
+		Peeks:        g.PeeksUsed,
+		Elapsed:      g.elapsed(),
+		Daily:        g.Daily,
+		CampaignMode: g.CampaignMode,
+		Moves:        g.Recording.Moves,
+	}
+	return json.NewEncoder(w).Encode(state)
+}
+
+// LoadState reads a snapshot written by SaveState and gets the game ready to
+// carry on from it. Call PlayMap afterwards to start playing.
+func (g *Game) LoadState(r io.Reader) error {
+	var state GameState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return fmt.Errorf("Couldn't read saved game: %w", err)
+	}
+
+	var m *Maze
+	var err error
+	if state.Seed != 0 {
+		if state.Width <= 0 || state.Height <= 0 {
+			return fmt.Errorf("Saved game has a bad maze size: %dx%d", state.Width, state.Height)
+		}
+		m, err = Generate(GenerateOptions{Width: state.Width, Height: state.Height, Seed: state.Seed, Algorithm: state.Algorithm})
+	} else {
+		m, err = g.levelReader(state.Map).Read()
+		if err == nil {
+			err = m.ComputePathLen()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	g.LoadMaze(m, state.Map)
+	g.Recording.Algorithm = state.Algorithm
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, move := range state.Moves {
+		if move.Bomb {
+			g.useBomb(move.X, move.Y)
+			g.Recording.recordBomb(move.X, move.Y)
+		} else {
+			g.movePlayer(move.X, move.Y)
+			g.Recording.record(move.X, move.Y)
+		}
+	}
+	// if the map file changed since the game was saved, the moves won't
+	// have ended up in the same place
+	if g.PlayerX != state.PlayerX || g.PlayerY != state.PlayerY || g.CurrentSteps != state.CurrentSteps {
+		return fmt.Errorf("Saved game doesn't match map %s anymore", state.Map)
+	}
+
+	g.Keys = state.Keys
+	g.Bombs = state.Bombs
+	g.Treasure = state.Treasure
+	g.CurrentCollisions = state.Collisions
+	g.HintsUsed = state.Hints
+	g.PeeksUsed = state.Peeks
+	g.Daily = state.Daily
+	g.CampaignMode = state.CampaignMode
+	if state.Elapsed > 0 {
+		g.StartTime = time.Now().Add(-state.Elapsed)
+	}
+	return nil
+}
+
+// saveAndQuit saves the stage being played to the save file and goes back to
+// the main menu.
+func (g *Game) saveAndQuit() error {
+	err := os.MkdirAll(filepath.Dir(g.SavePath), 0755)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := g.SaveState(&buf); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(g.SavePath, buf.Bytes()); err != nil {
+		return err
+	}
+
+	g.ClearGame()
+	g.MainMenu()
+	return nil
+}
+
+// continueGame loads the saved game and starts playing it. The save is
+// deleted once it's loaded, so it can only be continued once.
+func (g *Game) continueGame() {
+	f, err := os.Open(g.SavePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		g.okModal("There's no saved game to continue.", "no_save")
+		return
+	} else if err != nil {
+		g.DisplayError(err)
+		return
+	}
+	err = g.LoadState(f)
+	f.Close()
+	if err != nil {
+		g.ClearGame()
+		g.DisplayError(err)
+		return
+	}
+
+	g.PlayMap(g.EndGame)
+	err = os.Remove(g.SavePath)
+	if err != nil {
+		g.DisplayError(err)
+	}
+}
@@ -0,0 +1,38 @@
+package maze
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSaveStateKeepsAlgorithm(t *testing.T) {
+	m, err := Generate(GenerateOptions{Width: 9, Height: 7, Seed: 42, Algorithm: ALGORITHM_PRIM})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Game{}
+	g.LoadMaze(m, "Seed 42")
+	g.Recording.Algorithm = ALGORITHM_PRIM
+	for _, dir := range []Direction{POS_X, POS_Y, NEG_X, NEG_Y} {
+		g.TryMove(dir)
+	}
+
+	var buf bytes.Buffer
+	if err := g.SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Game{}
+	if err := loaded.LoadState(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !loaded.CurrentMap.Equal(g.CurrentMap) {
+		t.Errorf("loaded a different maze:\n%s\nwant:\n%s", loaded.CurrentMap, g.CurrentMap)
+	}
+	if loaded.PlayerX != g.PlayerX || loaded.PlayerY != g.PlayerY || loaded.CurrentSteps != g.CurrentSteps {
+		t.Errorf("player is at %d, %d after %d steps, want %d, %d after %d", loaded.PlayerX, loaded.PlayerY, loaded.CurrentSteps, g.PlayerX, g.PlayerY, g.CurrentSteps)
+	}
+	if loaded.Recording.Algorithm != ALGORITHM_PRIM {
+		t.Errorf("recording algorithm is %v, want prim", loaded.Recording.Algorithm)
+	}
+}
@@ -0,0 +1,100 @@
+package maze
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalcScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     int
+		bestSteps int
+		min, max  float64
+	}{
+		{"equal to best", 40, 40, 1000000, 1000000},
+		{"below best", 30, 40, 1000000, 1000000},
+		{"far below best", 0, 40, 1000000, 1000000},
+		{"a long way below best", 0, 20000, 1000000, 1000000},
+		{"a little over", 56, 40, 400000, 600000},
+		{"far above best", 100000, 40, 0, 1},
+		{"best unknown", 40, -1, 0, 1000000},
+	}
+	for _, test := range tests {
+		got := CalcScore(test.steps, test.bestSteps)
+		if got < test.min || got > test.max || math.IsNaN(got) {
+			t.Errorf("%s: CalcScore(%d, %d) = %g, want between %g and %g", test.name, test.steps, test.bestSteps, got, test.min, test.max)
+		}
+	}
+}
+
+func TestCalcScoreWith(t *testing.T) {
+	cfg := ScoreConfig{MaxScore: 500, Softness: 5}
+	tests := []struct {
+		name      string
+		steps     int
+		bestSteps int
+		min, max  float64
+	}{
+		{"equal to best", 20, 20, 500, 500},
+		{"below best", 10, 20, 500, 500},
+		{"far above best", 100000, 20, 0, 0.001},
+	}
+	for _, test := range tests {
+		got := CalcScoreWith(cfg, test.steps, test.bestSteps)
+		if got < test.min || got > test.max || math.IsNaN(got) {
+			t.Errorf("%s: CalcScoreWith(%d, %d) = %g, want between %g and %g", test.name, test.steps, test.bestSteps, got, test.min, test.max)
+		}
+	}
+
+	if got := CalcScoreWith(ScoreConfig{}, 20, 20); got != DefaultScoreConfig().MaxScore {
+		t.Errorf("zero config: got %g, want the default max score", got)
+	}
+
+	// a softer curve is more forgiving for the same number of extra steps
+	if soft, hard := CalcScoreWith(ScoreConfig{MaxScore: 500, Softness: 30}, 30, 20), CalcScoreWith(cfg, 30, 20); soft <= hard {
+		t.Errorf("softer curve scored %g, not more than %g", soft, hard)
+	}
+}
+
+func TestEndlessMultiplier(t *testing.T) {
+	tests := []struct {
+		round int
+		want  float64
+	}{
+		{-10, 1},
+		{-1, 1},
+		{0, 1},
+		{1, 1 + 1.0/32},
+		{4, 1.5},
+		{8, 3},
+	}
+	for _, test := range tests {
+		if got := EndlessMultiplier(test.round); got != test.want {
+			t.Errorf("EndlessMultiplier(%d) = %g, want %g", test.round, got, test.want)
+		}
+	}
+}
+
+func TestCalcScoreEndless(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     int
+		bestSteps int
+		round     int
+		min, max  float64
+	}{
+		{"equal to best at round 0", 40, 40, 0, 1000000, 1000000},
+		{"below best at round 0", 30, 40, 0, 1000000, 1000000},
+		{"equal to best at round 4", 40, 40, 4, 1500000, 1500000},
+		{"below best at round 4", 30, 40, 4, 1500000, 1500000},
+		{"negative round", 40, 40, -3, 1000000, 1000000},
+		{"far above best", 100000, 40, 4, 0, 1.5},
+	}
+	for _, test := range tests {
+		got := CalcScoreEndless(test.steps, test.bestSteps, test.round)
+		if got < test.min || got > test.max || math.IsNaN(got) {
+			t.Errorf("%s: CalcScoreEndless(%d, %d, %d) = %g, want between %g and %g", test.name, test.steps, test.bestSteps, test.round, got, test.min, test.max)
+		}
+	}
+}
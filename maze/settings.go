@@ -0,0 +1,57 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Settings are the player's choices that are kept between games. Like
+// Highscores they're saved to a JSON file.
+type Settings struct {
+	Path string `json:"-"`
+
+	// Theme is the name of the color theme
+	Theme string `json:"theme,omitempty"`
+}
+
+// NewSettings creates empty Settings backed by the file at path. Call Load to
+// read in the settings that are already saved.
+func NewSettings(path string) *Settings {
+	return &Settings{Path: path}
+}
+
+// Load reads the settings from their file. A missing file is fine, it just
+// means nothing has been changed yet.
+func (s *Settings) Load() error {
+	content, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(content, s)
+	if err != nil {
+		return fmt.Errorf("Could not read settings from %s: %v", s.Path, err)
+	}
+	return nil
+}
+
+// Save writes the settings to their file, creating its directory if it
+// doesn't exist yet.
+func (s *Settings) Save() error {
+	content, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.Path), 0755)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.Path, content)
+}
@@ -0,0 +1,40 @@
+package maze
+
+import (
+	"time"
+
+	tcell "github.com/gdamore/tcell/v2"
+)
+
+// BEEP_GAP is how long to wait between beeps when there's more than one.
+const BEEP_GAP = 150 * time.Millisecond
+
+// beep rings the terminal bell times times if sound is turned on. One beep
+// means a move was blocked and two means the stage was won.
+// tview doesn't hand out its screen, so the bell gets rung after the next
+// draw instead. This has to be called from the UI thread.
+func (g *Game) beep(times int) {
+	if !g.SoundEnabled {
+		return
+	}
+	g.pendingBeeps += times
+	g.Application.SetAfterDrawFunc(g.ringBell)
+}
+
+// ringBell is the after draw function that rings the bell for beep. If there
+// are more beeps waiting it asks for another draw a little later to ring the
+// next one, so they don't all run together.
+func (g *Game) ringBell(screen tcell.Screen) {
+	if g.pendingBeeps == 0 {
+		return
+	}
+	screen.Beep()
+	g.pendingBeeps--
+	if g.pendingBeeps > 0 {
+		go func() {
+			defer g.Recover()
+			time.Sleep(BEEP_GAP)
+			g.Application.QueueUpdateDraw(func() {})
+		}()
+	}
+}
@@ -0,0 +1,126 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MAX_STARS is the most stars a level can be beaten with.
+const MAX_STARS = 3
+
+// StarRating rates a win from 1 to 3 stars by how close steps was to the
+// shortest path. Taking the shortest path gets 3 stars, taking up to half as
+// many steps again gets 2, and anything longer still gets 1 for finishing.
+func StarRating(steps int, pathLen int) int {
+	if steps <= pathLen {
+		return MAX_STARS
+	}
+	if steps <= pathLen+pathLen/2 {
+		return 2
+	}
+	return 1
+}
+
+// Stars keeps the best star rating each level has been beaten with. Like
+// Highscores it's saved to a JSON file.
+type Stars struct {
+	Path string         `json:"-"`
+	Best map[string]int `json:"best"`
+}
+
+// NewStars creates an empty Stars backed by the file at path. Call Load to
+// read in the ratings that are already saved.
+func NewStars(path string) *Stars {
+	return &Stars{Path: path, Best: make(map[string]int)}
+}
+
+// Load reads the ratings from their file. A missing file is fine, it just
+// means no level has been beaten yet.
+func (s *Stars) Load() error {
+	content, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(content, s)
+	if err != nil {
+		return fmt.Errorf("Could not read stars from %s: %v", s.Path, err)
+	}
+	if s.Best == nil {
+		s.Best = make(map[string]int)
+	}
+	return nil
+}
+
+// Save writes the ratings to their file, creating its directory if it doesn't
+// exist yet.
+func (s *Stars) Save() error {
+	content, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.Path), 0755)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.Path, content)
+}
+
+// Record keeps stars as the rating for the map if it's better than the one
+// it already has.
+func (s *Stars) Record(mapName string, stars int) {
+	if best, ok := s.Best[mapName]; !ok || stars > best {
+		s.Best[mapName] = stars
+	}
+}
+
+// Rating returns the best rating the map has been beaten with, if it has
+// been beaten.
+func (s *Stars) Rating(mapName string) (int, bool) {
+	stars, ok := s.Best[mapName]
+	return stars, ok
+}
+
+// starText draws a rating as filled and empty stars.
+func starText(stars int) string {
+	stars = max(0, min(stars, MAX_STARS))
+	return strings.Repeat("★", stars) + strings.Repeat("☆", MAX_STARS-stars)
+}
+
+// recordStars saves the star rating for a won level and returns a line for
+// the end screen with the rating.
+func (g *Game) recordStars(s *Score) (string, error) {
+	stars := StarRating(s.Steps, g.CurrentMap.PathLen)
+	g.Stars.Record(s.Map, stars)
+	err := g.Stars.Save()
+	// the level select gets made again with the new stars on it
+	g.Pages.RemovePage("map_select")
+	return fmt.Sprintf("\nStars: %s", starText(stars)), err
+}
+
+// levelLabel is the button label for a map on the level select, with its
+// title if it has one, how hard it is and the best star rating it's been
+// beaten with.
+func (g *Game) levelLabel(name string) string {
+	label := name
+	// a map that can't be read just gets its name, the error shows up
+	// when it's picked
+	if m, err := g.level(name); err == nil {
+		if m.Title != "" {
+			label = m.Title
+		}
+		label += fmt.Sprintf(" (%s)", DifficultyLabel(m.Difficulty()))
+	}
+	if stars, ok := g.Stars.Rating(name); ok {
+		label += " " + starText(stars)
+	}
+	return label
+}
@@ -0,0 +1,29 @@
+package maze
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStarsKeepBest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stars.json")
+	s := NewStars(path)
+	s.Record("maze_1", 2)
+	s.Record("maze_1", 1)
+	s.Record("maze_1", 3)
+	s.Record("maze_1", 2)
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewStars(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if stars, ok := loaded.Rating("maze_1"); !ok || stars != 3 {
+		t.Errorf("got %d stars, want 3", stars)
+	}
+	if _, ok := loaded.Rating("maze_2"); ok {
+		t.Error("maze_2 has a rating without being beaten")
+	}
+}
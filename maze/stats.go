@@ -0,0 +1,102 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Stats are totals over every stage the player has ever finished. Like
+// Highscores they're saved to a JSON file. The Endless goroutine and the UI
+// can both get at them, so everything goes through a mutex.
+type Stats struct {
+	Path string `json:"-"`
+
+	Won        int `json:"won"`
+	Lost       int `json:"lost"`
+	TotalSteps int `json:"total_steps"`
+	BestScore  int `json:"best_score"`
+
+	mu sync.Mutex
+}
+
+// NewStats creates empty Stats backed by the file at path. Call Load to read
+// in the stats that are already saved.
+func NewStats(path string) *Stats {
+	return &Stats{Path: path}
+}
+
+// Load reads the stats from their file. A missing file is fine, it just means
+// nothing has been played yet.
+func (s *Stats) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(content, s)
+	if err != nil {
+		return fmt.Errorf("Could not read stats from %s: %v", s.Path, err)
+	}
+	return nil
+}
+
+// Record adds a finished stage to the stats and saves them.
+func (s *Stats) Record(score *Score) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if score.Won {
+		s.Won++
+		s.BestScore = max(s.BestScore, score.Score)
+	} else {
+		s.Lost++
+	}
+	s.TotalSteps += score.Steps
+	return s.save()
+}
+
+// save writes the stats to their file. The caller has to hold the lock.
+func (s *Stats) save() error {
+	content, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.Path), 0755)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.Path, content)
+}
+
+// String shows the stats the way the stats screen does.
+func (s *Stats) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ratio := "---"
+	if s.Won+s.Lost > 0 {
+		ratio = fmt.Sprintf("%.0f%%", 100*float64(s.Won)/float64(s.Won+s.Lost))
+	}
+	return fmt.Sprintf(`STATS
+
+Mazes completed: %d
+Mazes failed: %d
+Win rate: %s
+Total steps: %d
+Best score: %d`, s.Won, s.Lost, ratio, s.TotalSteps, s.BestScore)
+}
+
+func (g *Game) displayStats() {
+	g.okModal(g.Stats.String(), "stats")
+}
@@ -0,0 +1,184 @@
+package maze
+
+import (
+	"fmt"
+
+	tcell "github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Theme is the set of colors the colored display draws with.
+type Theme struct {
+	Name string
+	// Tiles are the color for each kind of tile. Tiles that aren't in here
+	// are drawn in the default color.
+	Tiles  map[Tile]tcell.Color
+	Player tcell.Color
+	Enemy  tcell.Color
+	// Portal is the color of every portal, whatever its number
+	Portal tcell.Color
+	// Path is the solution drawn over the board, and Trail is where the
+	// player has already been
+	Path  tcell.Color
+	Trail tcell.Color
+}
+
+// ClassicTheme is the colors the game has always used.
+func ClassicTheme() Theme {
+	return Theme{
+		Name: "Classic",
+		Tiles: map[Tile]tcell.Color{
+			TILE_WALL:     tcell.ColorBlue,
+			TILE_START:    tcell.ColorGreen,
+			TILE_END:      tcell.ColorRed,
+			TILE_KEY:      tcell.ColorFuchsia,
+			TILE_DOOR:     tcell.ColorOrange,
+			TILE_MUD:      tcell.ColorOlive,
+			TILE_ICE:      tcell.ColorWhite,
+			TILE_BOMB:     tcell.ColorMaroon,
+			TILE_TREASURE: tcell.ColorGold,
+
+			TILE_ONEWAY_UP:    tcell.ColorSilver,
+			TILE_ONEWAY_DOWN:  tcell.ColorSilver,
+			TILE_ONEWAY_LEFT:  tcell.ColorSilver,
+			TILE_ONEWAY_RIGHT: tcell.ColorSilver,
+		},
+		Player: tcell.ColorYellow,
+		Enemy:  tcell.ColorRed,
+		Portal: tcell.ColorAqua,
+		Path:   tcell.ColorYellow,
+		Trail:  tcell.ColorGray,
+	}
+}
+
+// HighContrastTheme uses only the brightest colors, with white walls, for
+// screens or eyes where the classic dark blue walls are hard to make out.
+func HighContrastTheme() Theme {
+	return Theme{
+		Name: "High Contrast",
+		Tiles: map[Tile]tcell.Color{
+			TILE_WALL:     tcell.ColorWhite,
+			TILE_START:    tcell.ColorLime,
+			TILE_END:      tcell.ColorRed,
+			TILE_KEY:      tcell.ColorFuchsia,
+			TILE_DOOR:     tcell.ColorOrange,
+			TILE_MUD:      tcell.ColorYellow,
+			TILE_ICE:      tcell.ColorAqua,
+			TILE_BOMB:     tcell.ColorRed,
+			TILE_TREASURE: tcell.ColorYellow,
+
+			TILE_ONEWAY_UP:    tcell.ColorLime,
+			TILE_ONEWAY_DOWN:  tcell.ColorLime,
+			TILE_ONEWAY_LEFT:  tcell.ColorLime,
+			TILE_ONEWAY_RIGHT: tcell.ColorLime,
+		},
+		Player: tcell.ColorYellow,
+		Enemy:  tcell.ColorRed,
+		Portal: tcell.ColorAqua,
+		Path:   tcell.ColorFuchsia,
+		Trail:  tcell.ColorSilver,
+	}
+}
+
+// ColorblindTheme uses the Okabe-Ito colors, which stay easy to tell apart
+// with the common kinds of colorblindness. In particular the start and end
+// aren't green and red anymore.
+func ColorblindTheme() Theme {
+	blue := tcell.NewHexColor(0x0072b2)
+	skyBlue := tcell.NewHexColor(0x56b4e9)
+	green := tcell.NewHexColor(0x009e73)
+	yellow := tcell.NewHexColor(0xf0e442)
+	orange := tcell.NewHexColor(0xe69f00)
+	vermillion := tcell.NewHexColor(0xd55e00)
+	purple := tcell.NewHexColor(0xcc79a7)
+	return Theme{
+		Name: "Colorblind",
+		Tiles: map[Tile]tcell.Color{
+			TILE_WALL:     blue,
+			TILE_START:    skyBlue,
+			TILE_END:      vermillion,
+			TILE_KEY:      purple,
+			TILE_DOOR:     orange,
+			TILE_MUD:      tcell.ColorGray,
+			TILE_ICE:      tcell.ColorWhite,
+			TILE_BOMB:     vermillion,
+			TILE_TREASURE: yellow,
+
+			TILE_ONEWAY_UP:    tcell.ColorSilver,
+			TILE_ONEWAY_DOWN:  tcell.ColorSilver,
+			TILE_ONEWAY_LEFT:  tcell.ColorSilver,
+			TILE_ONEWAY_RIGHT: tcell.ColorSilver,
+		},
+		Player: yellow,
+		Enemy:  vermillion,
+		Portal: green,
+		Path:   yellow,
+		Trail:  tcell.ColorGray,
+	}
+}
+
+// Themes are all the built in themes, in the order the settings list them.
+func Themes() []Theme {
+	return []Theme{ClassicTheme(), HighContrastTheme(), ColorblindTheme()}
+}
+
+// ThemeByName finds the built in theme called name.
+func ThemeByName(name string) (Theme, bool) {
+	for _, t := range Themes() {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Theme{}, false
+}
+
+// SetTheme changes the colors the game is drawn in.
+func (g *Game) SetTheme(t Theme) {
+	g.Theme = t
+}
+
+// colorTag turns a color into something that can go in a tview color tag.
+func colorTag(c tcell.Color) string {
+	return fmt.Sprintf("#%06x", c.Hex())
+}
+
+// tileColor is the color tag for a tile, if the theme has a color for it.
+func (t Theme) tileColor(tile Tile) (string, bool) {
+	if tile.IsPortal() {
+		return colorTag(t.Portal), true
+	}
+	c, ok := t.Tiles[tile]
+	if !ok {
+		return "", false
+	}
+	return colorTag(c), true
+}
+
+// displaySettings lets the player pick a theme. The choice is saved so it's
+// still picked next time.
+func (g *Game) displaySettings() {
+	themes := Themes()
+	names := make([]string, len(themes))
+	for i, t := range themes {
+		names[i] = t.Name
+	}
+
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("SETTINGS\n\nPick a color theme. The current one is %s.", g.Theme.Name)).
+		AddButtons(names).
+		AddButtons([]string{"Back"})
+	modal.SetDoneFunc(func(i int, label string) {
+		g.Pages.RemovePage("settings")
+		g.Pages.SwitchToPage("menu")
+		if label == "Back" {
+			return
+		}
+
+		g.SetTheme(themes[i])
+		g.Settings.Theme = themes[i].Name
+		if err := g.Settings.Save(); err != nil {
+			g.DisplayError(err)
+		}
+	})
+	g.Pages.AddAndSwitchToPage("settings", modal, false)
+}
@@ -0,0 +1,85 @@
+package maze
+
+import (
+	"time"
+)
+
+// STEPS_PER_SECOND is how fast a good player is expected to move in timed
+// mode. It's used to work out the par time for a maze.
+const STEPS_PER_SECOND float64 = 5
+
+// elapsed returns how long the current stage has been going for, or zero if
+// the player hasn't moved yet. Time spent in the pause menu doesn't count.
+func (g *Game) elapsed() time.Duration {
+	if g.StartTime.IsZero() {
+		return 0
+	}
+	paused := g.PausedTime
+	if !g.pausedAt.IsZero() {
+		paused += time.Since(g.pausedAt)
+	}
+	return time.Since(g.StartTime) - paused
+}
+
+// pauseClock stops the clock counting until resumeClock is called.
+func (g *Game) pauseClock() {
+	if g.pausedAt.IsZero() {
+		g.pausedAt = time.Now()
+	}
+}
+
+// resumeClock starts the clock again after pauseClock. The ticking on screen
+// starts again on the next move.
+func (g *Game) resumeClock() {
+	if !g.pausedAt.IsZero() {
+		g.PausedTime += time.Since(g.pausedAt)
+		g.pausedAt = time.Time{}
+	}
+}
+
+// resetClock puts the clock back to before the first move.
+func (g *Game) resetClock() {
+	g.stopTimer()
+	g.StartTime = time.Time{}
+	g.PausedTime = 0
+	g.pausedAt = time.Time{}
+}
+
+// startTimer starts a goroutine that calls redraw on the UI thread a few
+// times a second so the clock on screen keeps ticking. It does nothing if the
+// timer is already running.
+func (g *Game) startTimer(redraw func()) {
+	if g.timerStop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	g.timerStop = stop
+	go func() {
+		defer g.Recover()
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				g.Application.QueueUpdateDraw(func() {
+					// the timer might have been stopped while this
+					// update was waiting in the queue
+					if g.timerStop == stop {
+						redraw()
+					}
+				})
+			}
+		}
+	}()
+}
+
+// stopTimer stops the goroutine started by startTimer, if there is one.
+func (g *Game) stopTimer() {
+	if g.timerStop != nil {
+		close(g.timerStop)
+		g.timerStop = nil
+	}
+}
@@ -0,0 +1,53 @@
+package maze
+
+import (
+	"testing"
+	"time"
+)
+
+// near reports whether got is within a second of want, which is plenty for
+// the few calls to time.Now between them.
+func near(got time.Duration, want time.Duration) bool {
+	diff := got - want
+	return diff > -time.Second && diff < time.Second
+}
+
+func TestPausedTimeDoesntCount(t *testing.T) {
+	g := &Game{}
+	if got := g.elapsed(); got != 0 {
+		t.Errorf("elapsed is %v before the first move", got)
+	}
+
+	// rather than sleeping, move the clock's times back: the stage started
+	// a minute ago and the player has been in the pause menu for 20 seconds
+	g.StartTime = time.Now().Add(-time.Minute)
+	g.pauseClock()
+	g.pausedAt = g.pausedAt.Add(-20 * time.Second)
+	if got := g.elapsed(); !near(got, 40*time.Second) {
+		t.Errorf("elapsed is %v while paused, want 40s", got)
+	}
+
+	// pausing again while paused doesn't start the pause over
+	g.pauseClock()
+	g.resumeClock()
+	if got := g.elapsed(); !near(got, 40*time.Second) {
+		t.Errorf("elapsed is %v after resuming, want 40s", got)
+	}
+
+	// a second pause adds to the first
+	g.pauseClock()
+	g.pausedAt = g.pausedAt.Add(-10 * time.Second)
+	g.resumeClock()
+	if got := g.elapsed(); !near(got, 30*time.Second) {
+		t.Errorf("elapsed is %v after two pauses, want 30s", got)
+	}
+	if !near(g.PausedTime, 30*time.Second) {
+		t.Errorf("PausedTime is %v, want 30s", g.PausedTime)
+	}
+
+	// resuming when the clock isn't paused does nothing
+	g.resumeClock()
+	if !near(g.PausedTime, 30*time.Second) {
+		t.Errorf("PausedTime is %v after resuming twice, want 30s", g.PausedTime)
+	}
+}
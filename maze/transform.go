@@ -0,0 +1,88 @@
+package maze
+
+// Rotate90 returns a copy of the maze turned a quarter turn clockwise. Like
+// the flips below, everything that has a position or a direction gets turned
+// with the board, so the new maze plays exactly like the old one. It isn't
+// the maze its seed makes anymore, so the copy has no Seed.
+func (m *Maze) Rotate90() *Maze {
+	h := m.Height
+	return m.transform(m.Height, m.Width,
+		func(c Coords) Coords { return Coords{X: h - 1 - c.Y, Y: c.X} },
+		func(dx, dy int) (int, int) { return -dy, dx })
+}
+
+// FlipHorizontal returns a copy of the maze mirrored left to right.
+func (m *Maze) FlipHorizontal() *Maze {
+	w := m.Width
+	return m.transform(m.Width, m.Height,
+		func(c Coords) Coords { return Coords{X: w - 1 - c.X, Y: c.Y} },
+		func(dx, dy int) (int, int) { return -dx, dy })
+}
+
+// FlipVertical returns a copy of the maze mirrored top to bottom.
+func (m *Maze) FlipVertical() *Maze {
+	h := m.Height
+	return m.transform(m.Width, m.Height,
+		func(c Coords) Coords { return Coords{X: c.X, Y: h - 1 - c.Y} },
+		func(dx, dy int) (int, int) { return dx, -dy })
+}
+
+// transform builds a width by height copy of the maze with every position
+// moved by move and every direction turned by turn.
+func (m *Maze) transform(width int, height int, move func(Coords) Coords, turn func(int, int) (int, int)) *Maze {
+	board := make([][]Tile, height)
+	for i := range board {
+		board[i] = make([]Tile, width)
+	}
+	for i, row := range m.Board {
+		for j, tile := range row {
+			if dx, dy, ok := tile.OneWay(); ok {
+				tile = oneWayTile(turn(dx, dy))
+			}
+			c := move(Coords{X: j, Y: i})
+			board[c.Y][c.X] = tile
+		}
+	}
+
+	t := &Maze{
+		Board:   board,
+		Start:   move(m.Start),
+		End:     move(m.End),
+		PathLen: m.PathLen,
+		Width:   width,
+		Height:  height,
+		Title:   m.Title,
+		Author:  m.Author,
+	}
+	for _, exit := range m.Exits {
+		t.Exits = append(t.Exits, move(exit))
+	}
+	if m.Portals != nil {
+		t.Portals = make(map[Coords]Coords, len(m.Portals))
+		for from, to := range m.Portals {
+			t.Portals[move(from)] = move(to)
+		}
+	}
+	for _, c := range m.Treasure {
+		t.Treasure = append(t.Treasure, move(c))
+	}
+	for _, e := range m.Enemies {
+		dx, dy := turn(e.DX, e.DY)
+		t.Enemies = append(t.Enemies, Enemy{Pos: move(e.Pos), DX: dx, DY: dy})
+	}
+	return t
+}
+
+// oneWayTile is the one-way tile that points in the direction dx, dy.
+func oneWayTile(dx int, dy int) Tile {
+	switch {
+	case dy < 0:
+		return TILE_ONEWAY_UP
+	case dy > 0:
+		return TILE_ONEWAY_DOWN
+	case dx < 0:
+		return TILE_ONEWAY_LEFT
+	default:
+		return TILE_ONEWAY_RIGHT
+	}
+}
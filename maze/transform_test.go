@@ -0,0 +1,61 @@
+package maze
+
+import "testing"
+
+func TestTransformsKeepMazesSolvable(t *testing.T) {
+	mazes := []*Maze{}
+	// a one-way tile only lets the player through if it gets turned with
+	// the board
+	m, err := LoadMazeFromString("#######\n#>.}.k#\n#.###D#\n#.mm.<#\n#######\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mazes = append(mazes, m)
+	for seed := int64(1); seed <= 5; seed++ {
+		m, err := GenerateMaze(9, 6, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mazes = append(mazes, m)
+	}
+
+	transforms := []struct {
+		name  string
+		apply func(*Maze) *Maze
+	}{
+		{"Rotate90", (*Maze).Rotate90},
+		{"FlipHorizontal", (*Maze).FlipHorizontal},
+		{"FlipVertical", (*Maze).FlipVertical},
+	}
+	for i, m := range mazes {
+		if err := m.ComputePathLen(); err != nil {
+			t.Fatal(err)
+		}
+		for _, transform := range transforms {
+			turned := transform.apply(m)
+			if transform.name == "Rotate90" {
+				if turned.Width != m.Height || turned.Height != m.Width {
+					t.Errorf("maze %d: %s made a %dx%d board from %dx%d", i, transform.name, turned.Width, turned.Height, m.Width, m.Height)
+				}
+			}
+			if turned.Board[turned.Start.Y][turned.Start.X] != TILE_START || turned.Board[turned.End.Y][turned.End.X] != TILE_END {
+				t.Errorf("maze %d: %s lost track of the start or end:\n%s", i, transform.name, turned)
+			}
+			if ok, err := turned.IsSolvable(); !ok || err != nil {
+				t.Errorf("maze %d: %s made it unsolvable (%v):\n%s", i, transform.name, err, turned)
+			}
+			want := m.PathLen
+			if err := turned.ComputePathLen(); err != nil || turned.PathLen != want {
+				t.Errorf("maze %d: %s changed the best path from %d to %d (%v)", i, transform.name, want, turned.PathLen, err)
+			}
+		}
+
+		back := m.Rotate90().Rotate90().Rotate90().Rotate90()
+		if !back.Equal(m) {
+			t.Errorf("maze %d: turning it all the way round got:\n%s\nwant:\n%s", i, back, m)
+		}
+		if !m.FlipHorizontal().FlipHorizontal().Equal(m) || !m.FlipVertical().FlipVertical().Equal(m) {
+			t.Errorf("maze %d: flipping it twice didn't get it back", i)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/downbtn/ap-maze/maze"
+)
+
+// validate loads every file in dir as a maze, checks it can be beaten and
+// prints how long the shortest way through it is. Maze files don't need any
+// particular extension, the built in ones don't have one, but hidden files
+// are skipped. It returns how many of them failed, so main can exit with an
+// error for CI.
+func validate(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	checked, failed := 0, 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		checked++
+		pathLen, err := validateFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL  %s: %v\n", entry.Name(), err)
+		} else {
+			fmt.Printf("ok    %s: shortest path %d\n", entry.Name(), pathLen)
+		}
+	}
+	if checked == 0 {
+		return 0, fmt.Errorf("No maze files in %s", dir)
+	}
+
+	fmt.Printf("%d of %d mazes passed\n", checked-failed, checked)
+	return failed, nil
+}
+
+// validateFile checks one maze file and returns its shortest path length.
+func validateFile(path string) (int, error) {
+	m, err := maze.LoadMazeFromFile(path)
+	if err != nil {
+		return 0, err
+	}
+	solvable, err := m.IsSolvable()
+	if err != nil {
+		return 0, err
+	} else if !solvable {
+		return 0, errors.New("The end can't be reached from the start")
+	}
+	err = m.ComputePathLen()
+	if err != nil {
+		return 0, err
+	}
+	return m.PathLen, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateBuiltinMazes(t *testing.T) {
+	failed, err := validate("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != 0 {
+		t.Errorf("%d of the built in mazes failed", failed)
+	}
+}
+
+func TestValidateReportsFailures(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"open":        "#####\n#>.<#\n#####\n",
+		"walled.maze": "#####\n#>#<#\n#####\n",
+		"broken":      "#####\n#>?<#\n#####\n",
+		".hidden":     "not a maze",
+		"second.maze": "#####\n#>..#\n###<#\n#####\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "folder"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	failed, err := validate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != 2 {
+		t.Errorf("%d mazes failed, want 2", failed)
+	}
+}
+
+func TestValidateEmptyDir(t *testing.T) {
+	if _, err := validate(t.TempDir()); err == nil {
+		t.Error("a folder with no mazes passed")
+	}
+}